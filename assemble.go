@@ -0,0 +1,192 @@
+package geosimplification
+
+import (
+	"errors"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// Build a valid polygon from directed edges, the interior on their left
+// like s2's Builder with a polygon layer (which the Go port lacks)
+//
+// vertices are snapped with `snapper`, vertices closer together than its
+// MinVertexSeparation are merged. Then edges collapsed to a point, duplicate
+// edges, and pairs of edges running both ways (zero width slivers) are
+// dropped. What's left is joined into loops, split wherever a loop visits
+// a vertex twice. Snapping doesn't untangle crossing edges, if any remain
+// the polygon is invalid and an error is returned
+func AssemblePolygon(edges []s2.Edge, snapper s2.Snapper) (*s2.Polygon, error) {
+	snap := newSiteIndex(snapper)
+
+	present := map[s2.Edge]bool{}
+	ordered := []s2.Edge{}
+	for _, edge := range edges {
+		snapped := s2.Edge{V0: snap.site(edge.V0), V1: snap.site(edge.V1)}
+		if snapped.V0 == snapped.V1 || present[snapped] {
+			continue
+		}
+		sibling := s2.Edge{V0: snapped.V1, V1: snapped.V0}
+		if present[sibling] {
+			delete(present, sibling)
+			continue
+		}
+		present[snapped] = true
+		ordered = append(ordered, snapped)
+	}
+
+	outgoing := map[s2.Point][]s2.Edge{}
+	remaining := 0
+	for _, edge := range ordered {
+		if present[edge] {
+			outgoing[edge.V0] = append(outgoing[edge.V0], edge)
+			remaining++
+		}
+	}
+
+	loops := []*s2.Loop{}
+	for _, edge := range ordered {
+		if !present[edge] || len(outgoing[edge.V0]) == 0 {
+			continue
+		}
+		// walk until we revisit a vertex, splitting off the loop between
+		path := []s2.Point{}
+		onPath := map[s2.Point]int{}
+		for vertex := edge.V0; ; {
+			if i, ok := onPath[vertex]; ok {
+				if len(path)-i > 2 {
+					loops = append(loops, s2.LoopFromPoints(append([]s2.Point{}, path[i:]...)))
+				}
+				for _, visited := range path[i:] {
+					delete(onPath, visited)
+				}
+				path = path[:i]
+			}
+			next := outgoing[vertex]
+			if len(next) == 0 {
+				if len(path) > 0 {
+					return nil, errors.New("edges don't form closed loops")
+				}
+				break
+			}
+			outgoing[vertex] = next[1:]
+			remaining--
+			onPath[vertex] = len(path)
+			path = append(path, vertex)
+			vertex = next[0].V1
+		}
+	}
+	if remaining > 0 {
+		return nil, errors.New("edges don't form closed loops")
+	}
+
+	if len(loops) == 0 {
+		return s2.PolygonFromLoops([]*s2.Loop{s2.EmptyLoop()}), nil
+	}
+	polygon := s2.PolygonFromOrientedLoops(loops)
+	if err := polygon.Validate(); err != nil {
+		return nil, err
+	}
+	if hasCrossings(polygon) {
+		return nil, errors.New("polygon has edges that cross")
+	}
+	return polygon, nil
+}
+
+// Validate doesn't look for crossing edges (yet), so we do
+func hasCrossings(shape s2.Shape) bool {
+	index := s2.NewShapeIndex()
+	index.Add(shape)
+	query := s2.NewCrossingEdgeQuery(index)
+	for i := 0; i < shape.NumEdges(); i++ {
+		edge := shape.Edge(i)
+		if len(query.Crossings(edge.V0, edge.V1, shape, s2.CrossingTypeInterior)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// The edges of the loop, in order
+func loopEdges(loop *s2.Loop) []s2.Edge {
+	edges := make([]s2.Edge, loop.NumEdges())
+	for i := range edges {
+		edges[i] = loop.Edge(i)
+	}
+	return edges
+}
+
+// Where vertices end up once snapped, nearby vertices share a site
+// sites are bucketed by cells at least MinVertexSeparation wide
+type siteIndex struct {
+	snapper    s2.Snapper
+	separation s1.ChordAngle
+	level      int
+	cells      map[s2.CellID][]s2.Point
+	sites      map[s2.Point]s2.Point
+}
+
+func newSiteIndex(snapper s2.Snapper) *siteIndex {
+	separation := snapper.MinVertexSeparation()
+	return &siteIndex{
+		snapper:    snapper,
+		separation: s1.ChordAngleFromAngle(separation),
+		level:      s2.MinWidthMetric.MaxLevel(separation.Radians()),
+		cells:      map[s2.CellID][]s2.Point{},
+		sites:      map[s2.Point]s2.Point{},
+	}
+}
+
+func (s *siteIndex) site(point s2.Point) s2.Point {
+	if site, ok := s.sites[point]; ok {
+		return site
+	}
+	candidate := s.snapper.SnapPoint(point)
+	site := candidate
+	if s.separation > 0 {
+		cell := s2.CellFromPoint(candidate).ID().Parent(s.level)
+		best := s.separation
+		for _, neighbour := range append(cell.AllNeighbors(s.level), cell) {
+			for _, existing := range s.cells[neighbour] {
+				if distance := s2.ChordAngleBetweenPoints(candidate, existing); distance < best {
+					site, best = existing, distance
+				}
+			}
+		}
+		if site == candidate {
+			s.cells[cell] = append(s.cells[cell], site)
+		}
+	}
+	s.sites[point] = site
+	return site
+}
+
+// Snap the output of SimplifyLoop and assemble it with AssemblePolygon
+func WithSnapper(snapper s2.Snapper) Option {
+	return func(o *options) {
+		o.snapper = snapper
+	}
+}
+
+// Like SimplifyLoop, but the output is assembled with AssemblePolygon
+// so a loop pinched by snapping becomes several, rather than an error
+// snaps with WithSnapper if given, otherwise vertices are left where they are
+// reports and stats describe the simplified loop before it's assembled
+func SimplifyLoopToPolygon(
+	loop *s2.Loop,
+	threshold float64,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	opts ...Option,
+) (output *s2.Polygon, err error) {
+	snapper := newOptions(opts).snapper
+	if snapper == nil {
+		snapper = s2.NewIdentitySnapper(0)
+	}
+
+	simplified, err := SimplifyLoop(loop, threshold, minPointsToKeep, avoidIntersections, append(opts[:len(opts):len(opts)], WithSnapper(nil))...)
+	if err != nil {
+		return nil, err
+	}
+	return AssemblePolygon(loopEdges(simplified), snapper)
+}
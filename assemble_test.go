@@ -0,0 +1,100 @@
+package geosimplification_test
+
+import (
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+func points(latLngs ...[2]float64) []s2.Point {
+	points := make([]s2.Point, len(latLngs))
+	for i, latLng := range latLngs {
+		points[i] = s2.PointFromLatLng(s2.LatLngFromDegrees(latLng[0], latLng[1]))
+	}
+	return points
+}
+
+// The edges joining `points`, closed
+func ringEdges(points ...s2.Point) []s2.Edge {
+	edges := make([]s2.Edge, len(points))
+	for i := range points {
+		edges[i] = s2.Edge{V0: points[i], V1: points[(i+1)%len(points)]}
+	}
+	return edges
+}
+
+var _ = Describe("Polygon assembly unit tests", func() {
+
+	square := points([2]float64{0, 0}, [2]float64{0, 1}, [2]float64{1, 1}, [2]float64{1, 0})
+
+	It("should drop degenerate, duplicate and sibling edges", func() {
+		spike := s2.PointFromLatLng(s2.LatLngFromDegrees(0.5, 0.5))
+		edges := append(ringEdges(square...),
+			s2.Edge{V0: square[0], V1: square[1]},
+			s2.Edge{V0: square[2], V1: square[2]},
+			s2.Edge{V0: square[2], V1: spike},
+			s2.Edge{V0: spike, V1: square[2]},
+		)
+		polygon, err := geosimplification.AssemblePolygon(edges, s2.NewIdentitySnapper(0))
+		Ω(err).Should(BeNil())
+		Ω(polygon.NumLoops()).Should(Equal(1))
+		Ω(polygon.Loop(0).NumVertices()).Should(Equal(4))
+	})
+
+	It("should split loops that visit a vertex twice", func() {
+		// two squares touching at a corner, walked as one figure of eight
+		figureEight := points(
+			[2]float64{0, 0}, [2]float64{0, 1}, [2]float64{1, 1},
+			[2]float64{2, 1}, [2]float64{2, 2}, [2]float64{1, 2},
+			[2]float64{1, 1}, [2]float64{1, 0},
+		)
+		polygon, err := geosimplification.AssemblePolygon(ringEdges(figureEight...), s2.NewIdentitySnapper(0))
+		Ω(err).Should(BeNil())
+		Ω(polygon.NumLoops()).Should(Equal(2))
+		Ω(polygon.Validate()).ShouldNot(HaveOccurred())
+	})
+
+	It("should merge vertices closer than the snap radius", func() {
+		near := s2.PointFromLatLng(s2.LatLngFromDegrees(1, 1.00001))
+		withNear := []s2.Point{square[0], square[1], square[2], near, square[3]}
+		polygon, err := geosimplification.AssemblePolygon(ringEdges(withNear...), s2.NewIdentitySnapper(0.001*s1.Degree))
+		Ω(err).Should(BeNil())
+		Ω(polygon.Loop(0).NumVertices()).Should(Equal(4))
+	})
+
+	It("should error on edges that cross", func() {
+		bowtie := points([2]float64{0, 0}, [2]float64{1, 1}, [2]float64{1, 0}, [2]float64{0, 1})
+		_, err := geosimplification.AssemblePolygon(ringEdges(bowtie...), s2.NewIdentitySnapper(0))
+		Ω(err).ShouldNot(BeNil())
+	})
+
+	It("should snap the output of SimplifyLoop to cell centers", func() {
+		loop := s2.LoopFromPoints(append([]s2.Point{}, square...))
+		snapper := s2.CellIDSnapperForLevel(20)
+		simplified, err := geosimplification.SimplifyLoop(loop, 0, 0, true, geosimplification.WithSnapper(snapper))
+		Ω(err).Should(BeNil())
+		Ω(simplified.Validate()).ShouldNot(HaveOccurred())
+		for _, vertex := range simplified.Vertices() {
+			Ω(vertex).Should(Equal(snapper.SnapPoint(vertex)))
+		}
+	})
+
+	It("should assemble SimplifyLoop output into a polygon", func() {
+		loop := s2.LoopFromPoints(append([]s2.Point{}, square...))
+		polygon, err := geosimplification.SimplifyLoopToPolygon(loop, 0, 0, true)
+		Ω(err).Should(BeNil())
+		Ω(polygon.NumLoops()).Should(Equal(1))
+		Ω(polygon.Loop(0).NumVertices()).Should(Equal(4))
+	})
+
+	It("should not write into the spare capacity of the caller's options", func() {
+		loop := s2.LoopFromPoints(append([]s2.Point{}, square...))
+		opts := make([]geosimplification.Option, 1, 2)
+		opts[0] = geosimplification.WithSnapper(s2.CellIDSnapperForLevel(20))
+		_, err := geosimplification.SimplifyLoopToPolygon(loop, 0, 0, true, opts...)
+		Ω(err).Should(BeNil())
+		Ω(opts[:2][1]).Should(BeNil())
+	})
+})
@@ -0,0 +1,63 @@
+package geosimplification
+
+import (
+	"errors"
+	"math"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+// Building mode for SimplifyLoop and SimplifyPolygon, keep right angles
+//
+// walls within `tolerance` of the building's dominant orientation are
+// squared up. A vertex between two square walls may only be removed if the
+// wall left is square too, so small steps go but corners stay. Loops of a
+// polygon share one orientation, so courtyards line up with the outside
+//
+// squaring moves vertices, so it can't be combined with containment,
+// preserved points or MaxAreaChange. If a squared loop crosses itself (or
+// with avoidIntersections another loop of the polygon) it's an error
+func Orthogonal(tolerance s1.Angle) Option {
+	return func(o *options) {
+		o.orthogonal = tolerance
+	}
+}
+
+// The orientation SimplifyPolygon found for all its loops
+func withOrthogonalFrame(frame *internal.Orthogonal) Option {
+	return func(o *options) {
+		o.orthogonalFrame = frame
+	}
+}
+
+// The building's orientation, nil if we're not in building mode
+func (o *options) orthogonalFrameFor(loops ...*s2.Loop) *internal.Orthogonal {
+	if o.orthogonal <= 0 {
+		return nil
+	}
+	if o.orthogonalFrame != nil {
+		return o.orthogonalFrame
+	}
+	vertices := make([][]s2.Point, len(loops))
+	for i, loop := range loops {
+		vertices[i] = loop.Vertices()
+	}
+	return internal.NewOrthogonal(o.orthogonal, vertices...)
+}
+
+// Squaring moves vertices, constraints only understand removing them
+// and area preservation moves them already
+func (o *options) validateOrthogonal() error {
+	if o.orthogonal <= 0 {
+		return nil
+	}
+	if o.preserveArea {
+		return errors.New("area preservation can't be combined with building mode")
+	}
+	if o.containment != Unconstrained || len(o.preservedPoints) > 0 || !math.IsInf(o.maxAreaChange, 1) {
+		return errors.New("building mode can't be combined with containment, preserved points or MaxAreaChange")
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package geosimplification_test
+
+import (
+	"math"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+// A building in London, turned `rotation` degrees, from x, y offsets in metres
+func building(rotation float64, xys ...[2]float64) *s2.Loop {
+	lat, lng := 51.5, -0.1
+	sin, cos := math.Sincos(rotation * math.Pi / 180)
+	points := make([]s2.Point, len(xys))
+	for i, xy := range xys {
+		x, y := xy[0]*cos-xy[1]*sin, xy[0]*sin+xy[1]*cos
+		points[i] = s2.PointFromLatLng(s2.LatLngFromDegrees(
+			lat+y/111320,
+			lng+x/(111320*math.Cos(lat*math.Pi/180)),
+		))
+	}
+	loop := s2.LoopFromPoints(points)
+	loop.Normalize()
+	return loop
+}
+
+func expectRightAngles(loop *s2.Loop) {
+	n := loop.NumVertices()
+	for i := 0; i < n; i++ {
+		angle := s2.Angle(loop.Vertex((i-1+n)%n), loop.Vertex(i), loop.Vertex((i+1)%n))
+		Ω(math.Mod(angle.Degrees(), 90)).Should(Or(
+			BeNumerically("<", 0.01),
+			BeNumerically(">", 89.99),
+		))
+	}
+}
+
+var _ = Describe("Building mode unit tests", func() {
+	// square metres to steradians
+	area := func(metres float64) float64 {
+		return metres / (6371e3 * 6371e3)
+	}
+
+	Context("given a wall with a small step", func() {
+		// the far wall is a little off square
+		loop := func() *s2.Loop {
+			return building(30, [2]float64{0, 0}, [2]float64{10, 0}, [2]float64{10, 0.5}, [2]float64{20, 0.5}, [2]float64{20.2, 10}, [2]float64{0, 10})
+		}
+
+		It("should skew the building without building mode", func() {
+			simplified, err := geosimplification.SimplifyLoop(loop(), area(4), 0, true)
+			Ω(err).Should(BeNil())
+			Ω(simplified.NumVertices()).Should(Equal(4))
+			angle := s2.Angle(simplified.Vertex(3), simplified.Vertex(0), simplified.Vertex(1))
+			Ω(angle.Degrees()).ShouldNot(BeNumerically("~", 90, 0.5))
+		})
+
+		It("should square it up in building mode", func() {
+			simplified, err := geosimplification.SimplifyLoop(loop(), area(4), 0, true,
+				geosimplification.Orthogonal(5*s1.Degree),
+			)
+			Ω(err).Should(BeNil())
+			Ω(simplified.NumVertices()).Should(Equal(4))
+			expectRightAngles(simplified)
+		})
+	})
+
+	It("should keep the corners", func() {
+		l := building(-12, [2]float64{0, 0}, [2]float64{20, 0}, [2]float64{20, 10}, [2]float64{10, 10}, [2]float64{10, 20}, [2]float64{0, 20})
+		simplified, err := geosimplification.SimplifyLoop(l, math.Inf(1), 0, true,
+			geosimplification.Orthogonal(5*s1.Degree),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified.NumVertices()).Should(Equal(6))
+		expectRightAngles(simplified)
+	})
+
+	It("should square courtyards with the building", func() {
+		polygon := s2.PolygonFromLoops([]*s2.Loop{
+			building(45, [2]float64{0, 0}, [2]float64{30, 0.3}, [2]float64{30, 30}, [2]float64{0, 30}),
+			building(45, [2]float64{10, 10}, [2]float64{20, 10}, [2]float64{20, 20}, [2]float64{10.2, 20}),
+		})
+		simplified, err := geosimplification.SimplifyPolygon(polygon, area(1), 0, true,
+			geosimplification.Orthogonal(5*s1.Degree),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified.NumLoops()).Should(Equal(2))
+		for _, loop := range simplified.Loops() {
+			expectRightAngles(loop)
+		}
+	})
+
+	It("should reject constraints squaring could break", func() {
+		stepped := building(30, [2]float64{0, 0}, [2]float64{10, 0}, [2]float64{10, 0.5}, [2]float64{20, 0.5}, [2]float64{20.2, 10}, [2]float64{0, 10})
+		for _, constraint := range []geosimplification.Option{
+			geosimplification.WithContainment(geosimplification.Outer),
+			geosimplification.MaxAreaChange(0.1),
+		} {
+			_, err := geosimplification.SimplifyLoop(stepped, area(4), 0, true,
+				geosimplification.Orthogonal(5*s1.Degree),
+				constraint,
+			)
+			Ω(err).Should(HaveOccurred())
+		}
+	})
+
+	Context("given a kinked wall under a notch", func() {
+		// straightening the wall cuts through the notch above it
+		kinked := [][2]float64{{0, 0}, {10, -0.3}, {20, 0}}
+
+		It("should reject a loop squared into crossing itself", func() {
+			notched := building(0, append(kinked,
+				[2]float64{20, 10}, [2]float64{10.5, 10}, [2]float64{10.5, -0.1},
+				[2]float64{9.5, -0.1}, [2]float64{9.5, 10}, [2]float64{0, 10},
+			)...)
+			_, err := geosimplification.SimplifyLoop(notched, 0, 0, true,
+				geosimplification.Orthogonal(5*s1.Degree),
+			)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("should reject a shell squared into crossing its hole", func() {
+			polygon := s2.PolygonFromLoops([]*s2.Loop{
+				building(0, append(kinked, [2]float64{20, 10}, [2]float64{0, 10})...),
+				building(0, [2]float64{9.5, -0.2}, [2]float64{10.5, -0.2}, [2]float64{10.5, 1}, [2]float64{9.5, 1}),
+			})
+			Ω(polygon.Validate()).Should(BeNil())
+			_, err := geosimplification.SimplifyPolygon(polygon, 0, 0, true,
+				geosimplification.Orthogonal(5*s1.Degree),
+			)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	It("should only apply to loops", func() {
+		_, err := geosimplification.SimplifyLine(s2.Polyline{s2.PointFromCoords(1, 0, 0), s2.PointFromCoords(0, 1, 0)}, 0, 0, true,
+			geosimplification.Orthogonal(5*s1.Degree),
+		)
+		Ω(err).Should(HaveOccurred())
+	})
+})
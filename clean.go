@@ -0,0 +1,87 @@
+package geosimplification
+
+import (
+	"errors"
+	"math"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+// Remove spikes from SimplifyLoop and SimplifyPolygon: vertices where the
+// angle is less than `degrees` and an edge either side is at least
+// `metres` long. Long thin spikes have too much area for Visvalingam
+// to remove, though they're barely visible
+func RemoveSpikes(degrees, metres float64) Option {
+	return func(o *options) {
+		o.spikeAngle = s1.Angle(degrees) * s1.Degree
+		o.spikeLength = MetresToAngle(metres)
+	}
+}
+
+// Remove slivers from SimplifyLoop and SimplifyPolygon, features less than
+// `metres` wide on average (2 area / perimeter)
+//
+// peninsulas (and inlets) that leave the loop and come back within `metres`
+// of where they left are cut off where they leave. SimplifyPolygon treats
+// sliver loops like loops smaller than MinLoopArea, see SmallLoops
+func RemoveSlivers(metres float64) Option {
+	return func(o *options) {
+		o.sliverWidth = MetresToAngle(metres)
+	}
+}
+
+// Cleaning only means something for loops, and moves nothing
+func (o *options) validateCleaning() error {
+	if o.spikeAngle < 0 || o.spikeLength < 0 || o.sliverWidth < 0 {
+		return errors.New("spike and sliver thresholds can't be negative")
+	}
+	if o.preserveArea && (o.spikeAngle > 0 || o.sliverWidth > 0) {
+		return errors.New("area preservation can't be combined with spike or sliver removal")
+	}
+	return nil
+}
+
+// Remove spikes, then slivers, from the ring before simplifying it
+// `constraints` are the main pass's, so they share its area budget
+func (o *options) clean(
+	ring *internal.PointWithTriangleRing,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	constraints []internal.Constraint,
+	stats *internal.Stats,
+) error {
+	run := func(cut internal.Cut) error {
+		cleaned, err := internal.CutRing(ring, cut, minPointsToKeep, avoidIntersections, constraints...)
+		stats.Intersections += cleaned.Intersections
+		stats.Vetoed += cleaned.Vetoed
+		return err
+	}
+	if o.spikeAngle > 0 {
+		if err := run(internal.Spikes(o.spikeAngle, o.spikeLength)); err != nil {
+			return err
+		}
+	}
+	if o.sliverWidth > 0 {
+		peninsulas, err := internal.Peninsulas(ring, o.sliverWidth)
+		if err != nil {
+			return err
+		}
+		return run(peninsulas)
+	}
+	return nil
+}
+
+// Whether the loop is less than RemoveSlivers wide on average
+func (o *options) isSliver(loop *s2.Loop) bool {
+	if o.sliverWidth <= 0 || loop.IsEmpty() || loop.IsFull() {
+		return false
+	}
+	perimeter := 0.0
+	for i := 0; i < loop.NumVertices(); i++ {
+		perimeter += loop.Vertex(i).Distance(loop.Vertex(i + 1)).Radians()
+	}
+	area := math.Min(loop.Area(), 4*math.Pi-loop.Area())
+	return 2*area/perimeter < o.sliverWidth.Radians()
+}
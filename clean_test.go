@@ -0,0 +1,136 @@
+package geosimplification_test
+
+import (
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+var _ = Describe("Spike and sliver unit tests", func() {
+
+	// from x, y (longitude, latitude) in degrees
+	loop := func(xys ...[2]float64) *s2.Loop {
+		latLngs := make([][2]float64, len(xys))
+		for i, xy := range xys {
+			latLngs[i] = [2]float64{xy[1], xy[0]}
+		}
+		loop := s2.LoopFromPoints(points(latLngs...))
+		loop.Normalize()
+		return loop
+	}
+
+	Context("given a square with a long thin spike", func() {
+		tip := [2]float64{0.5, 3}
+		spiked := func() *s2.Loop {
+			return loop(
+				[2]float64{0, 0}, [2]float64{1, 0}, [2]float64{1, 1},
+				[2]float64{0.51, 1}, tip, [2]float64{0.49, 1}, [2]float64{0, 1},
+			)
+		}
+
+		It("should remove the spike", func() {
+			cleaned, err := geosimplification.SimplifyLoop(spiked(), 0, 0, true,
+				geosimplification.RemoveSpikes(5, 1000),
+			)
+			Ω(err).Should(BeNil())
+			Ω(cleaned.NumVertices()).Should(Equal(6))
+			Ω(cleaned.Vertices()).ShouldNot(ContainElement(points([2]float64{tip[1], tip[0]})[0]))
+			Ω(cleaned.Validate()).Should(BeNil())
+		})
+
+		It("should keep a spike with short edges", func() {
+			cleaned, err := geosimplification.SimplifyLoop(spiked(), 0, 0, true,
+				geosimplification.RemoveSpikes(5, 1e6),
+			)
+			Ω(err).Should(BeNil())
+			Ω(cleaned.NumVertices()).Should(Equal(7))
+		})
+
+		It("should keep a spike that would break the area budget", func() {
+			cleaned, err := geosimplification.SimplifyLoop(spiked(), 0, 0, true,
+				geosimplification.RemoveSpikes(5, 1000),
+				geosimplification.MaxAreaChange(0.01),
+			)
+			Ω(err).Should(BeNil())
+			Ω(cleaned.NumVertices()).Should(Equal(7))
+		})
+
+		It("should keep a spike that isn't sharp enough", func() {
+			cleaned, err := geosimplification.SimplifyLoop(spiked(), 0, 0, true,
+				geosimplification.RemoveSpikes(0.1, 1000),
+			)
+			Ω(err).Should(BeNil())
+			Ω(cleaned.NumVertices()).Should(Equal(7))
+		})
+	})
+
+	It("should cut off a narrow peninsula", func() {
+		// ~2km wide, 200km long
+		peninsula := loop(
+			[2]float64{0, 0}, [2]float64{1, 0}, [2]float64{1, 1},
+			[2]float64{0.51, 1}, [2]float64{0.51, 3}, [2]float64{0.49, 3}, [2]float64{0.49, 1},
+			[2]float64{0, 1},
+		)
+		cleaned, err := geosimplification.SimplifyLoop(peninsula, 0, 0, true,
+			geosimplification.RemoveSlivers(5000),
+		)
+		Ω(err).Should(BeNil())
+		Ω(cleaned.NumVertices()).Should(Equal(6))
+		Ω(cleaned.Validate()).Should(BeNil())
+		Ω(cleaned.Area()).Should(BeNumerically("<", peninsula.Area()))
+
+		kept, err := geosimplification.SimplifyLoop(peninsula, 0, 0, true,
+			geosimplification.RemoveSlivers(1000),
+		)
+		Ω(err).Should(BeNil())
+		Ω(kept.NumVertices()).Should(Equal(8))
+	})
+
+	It("should keep preserved points without avoiding intersections", func() {
+		spiked := loop(
+			[2]float64{0, 0}, [2]float64{1, 0}, [2]float64{1, 1},
+			[2]float64{0.51, 1}, [2]float64{0.5, 3}, [2]float64{0.49, 1}, [2]float64{0, 1},
+		)
+		inSpike := points([2]float64{2, 0.5})[0]
+		cleaned, err := geosimplification.SimplifyLoop(spiked, 0, 0, false,
+			geosimplification.RemoveSpikes(5, 1000),
+			geosimplification.PreservePoints(geosimplification.LabelledPoint{Point: inSpike, Inside: true}),
+		)
+		Ω(err).Should(BeNil())
+		Ω(cleaned.ContainsPoint(inSpike)).Should(BeTrue())
+
+		peninsula := loop(
+			[2]float64{0, 0}, [2]float64{1, 0}, [2]float64{1, 1},
+			[2]float64{0.51, 1}, [2]float64{0.51, 3}, [2]float64{0.49, 3}, [2]float64{0.49, 1},
+			[2]float64{0, 1},
+		)
+		inPeninsula := points([2]float64{2.5, 0.505})[0]
+		cleaned, err = geosimplification.SimplifyLoop(peninsula, 0, 0, false,
+			geosimplification.RemoveSlivers(5000),
+			geosimplification.PreservePoints(geosimplification.LabelledPoint{Point: inPeninsula, Inside: true}),
+		)
+		Ω(err).Should(BeNil())
+		Ω(cleaned.ContainsPoint(inPeninsula)).Should(BeTrue())
+	})
+
+	It("should drop sliver loops from a polygon", func() {
+		square := loop([2]float64{0, 0}, [2]float64{1, 0}, [2]float64{1, 1}, [2]float64{0, 1})
+		sliver := loop([2]float64{2, 0}, [2]float64{2.01, 0}, [2]float64{2.01, 1}, [2]float64{2, 1})
+		var indices []int
+		cleaned, err := geosimplification.SimplifyPolygon(s2.PolygonFromLoops([]*s2.Loop{square, sliver}), 0, 0, true,
+			geosimplification.RemoveSlivers(5000),
+			geosimplification.WithSmallLoops(&indices),
+		)
+		Ω(err).Should(BeNil())
+		Ω(cleaned.NumLoops()).Should(Equal(1))
+		Ω(indices).Should(Equal([]int{1}))
+	})
+
+	It("should only clean loops", func() {
+		_, err := geosimplification.SimplifyLine(s2.Polyline(points([2]float64{0, 0}, [2]float64{1, 1})), 0, 0, true,
+			geosimplification.RemoveSpikes(5, 1000),
+		)
+		Ω(err).ShouldNot(BeNil())
+	})
+})
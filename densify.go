@@ -0,0 +1,95 @@
+package geosimplification
+
+import (
+	"math"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// How dense edges must be, zero values don't apply
+type Densification struct {
+	// No edge longer than MaxLength
+	MaxLength s1.Angle
+	// Edges are drawn straight in Projection, e.g: s2.NewMercatorProjection(180)
+	// for Web Mercator. No edge drawn strays more than MaxDeviation from
+	// the geodesic it stands for
+	Projection   s2.Projection
+	MaxDeviation s1.Angle
+}
+
+// Insert points along edges that break `limits`, the points are on the
+// geodesic so the shape is unchanged. e.g: so simplified output projected
+// to Web Mercator still follows the great circles it's made of
+func DensifyLine(polyline s2.Polyline, limits Densification) s2.Polyline {
+	if len(polyline) < 2 {
+		return polyline
+	}
+	densified := s2.Polyline{polyline[0]}
+	for i := 1; i < len(polyline); i++ {
+		densified = limits.appendEdge(densified, polyline[i-1], polyline[i])
+	}
+	return densified
+}
+
+// Like DensifyLine for loops
+func DensifyLoop(loop *s2.Loop, limits Densification) *s2.Loop {
+	if loop.IsEmpty() || loop.IsFull() {
+		return loop
+	}
+	o := &options{densification: &limits}
+	return s2.LoopFromPoints(o.densify(loop.Vertices(), true))
+}
+
+// Densify the output of SimplifyLine, SimplifyLoop and friends, after
+// smoothing and before snapping
+func Densify(limits Densification) Option {
+	return func(o *options) {
+		o.densification = &limits
+	}
+}
+
+// Densify a line, or a ring (first point not repeated), if asked to
+func (o *options) densify(points []s2.Point, ring bool) []s2.Point {
+	if o.densification == nil || len(points) < 2 {
+		return points
+	}
+	if !ring {
+		return DensifyLine(points, *o.densification)
+	}
+	closed := DensifyLine(append(append(s2.Polyline{}, points...), points[0]), *o.densification)
+	return closed[:len(closed)-1]
+}
+
+// Append the edge ab, bar a, split to within the limits
+func (d Densification) appendEdge(points []s2.Point, a, b s2.Point) []s2.Point {
+	pieces := 1
+	if length := a.Distance(b); d.MaxLength > 0 && length > d.MaxLength {
+		pieces = int(math.Ceil(float64(length / d.MaxLength)))
+	}
+	from := a
+	for i := 1; i <= pieces; i++ {
+		to := b
+		if i < pieces {
+			to = s2.Interpolate(float64(i)/float64(pieces), a, b)
+		}
+		points = d.appendProjected(points, from, to)
+		from = to
+	}
+	return points
+}
+
+// Append the edge ab, bar a, split until it's drawn within MaxDeviation
+func (d Densification) appendProjected(points []s2.Point, a, b s2.Point) []s2.Point {
+	if d.Projection == nil || d.MaxDeviation <= 0 {
+		return append(points, b)
+	}
+	tessellator := s2.NewEdgeTessellator(d.Projection, d.MaxDeviation)
+	projected := tessellator.AppendProjected(a, b, []r2.Point{})
+	// the vertices between are midpoints on the geodesic, keep the ends exact
+	for _, point := range projected[1 : len(projected)-1] {
+		points = append(points, d.Projection.Unproject(point))
+	}
+	return append(points, b)
+}
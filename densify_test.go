@@ -0,0 +1,96 @@
+package geosimplification_test
+
+import (
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+var _ = Describe("Densification unit tests", func() {
+
+	It("should split long edges evenly", func() {
+		line := s2.Polyline(points([2]float64{0, 0}, [2]float64{0, 10}, [2]float64{0, 10.5}))
+		densified := geosimplification.DensifyLine(line, geosimplification.Densification{MaxLength: s1.Degree})
+		Ω(densified).Should(HaveLen(12))
+		Ω(densified[0]).Should(Equal(line[0]))
+		Ω(densified[10]).Should(Equal(line[1]))
+		Ω(densified[11]).Should(Equal(line[2]))
+		for i := 1; i < len(densified); i++ {
+			Ω(densified[i-1].Distance(densified[i])).Should(BeNumerically("<=", s1.Degree+1e-12))
+		}
+		for _, point := range densified[:11] {
+			Ω(s2.DistanceFromSegment(point, line[0], line[1])).Should(BeNumerically("<", s1.Angle(1e-12)))
+		}
+	})
+
+	Context("given a long edge drawn in Web Mercator", func() {
+		// the great circle bulges towards the pole, mercator draws it straight
+		line := s2.Polyline(points([2]float64{60, -30}, [2]float64{60, 30}))
+		mercator := s2.NewMercatorProjection(180)
+		deviation := s1.Angle(1e3 / 6371e3)
+
+		// how far the edges, drawn straight in mercator, stray from the line
+		furthest := func(densified s2.Polyline) s1.Angle {
+			max := s1.Angle(0)
+			for i := 1; i < len(densified); i++ {
+				a, b := mercator.Project(densified[i-1]), mercator.Project(densified[i])
+				for _, f := range []float64{0.25, 0.5, 0.75} {
+					drawn := mercator.Unproject(mercator.Interpolate(f, a, b))
+					if d := s2.DistanceFromSegment(drawn, line[0], line[1]); d > max {
+						max = d
+					}
+				}
+			}
+			return max
+		}
+
+		It("should stray by hundreds of kilometres undensified", func() {
+			Ω(furthest(line)).Should(BeNumerically(">", 100*deviation))
+		})
+
+		It("should stay within the deviation densified", func() {
+			densified := geosimplification.DensifyLine(line, geosimplification.Densification{
+				Projection:   mercator,
+				MaxDeviation: deviation,
+			})
+			Ω(len(densified)).Should(BeNumerically(">", 2))
+			Ω(furthest(densified)).Should(BeNumerically("<=", deviation))
+		})
+	})
+
+	It("should densify the output of simplification", func() {
+		line := s2.Polyline(points([2]float64{0, 0}, [2]float64{0, 1}, [2]float64{0.001, 2}, [2]float64{0, 3}))
+		var stats geosimplification.Stats
+		simplified, err := geosimplification.SimplifyLine(line, 1, 0, true,
+			geosimplification.Densify(geosimplification.Densification{MaxLength: 0.7 * s1.Degree}),
+			geosimplification.WithStats(&stats),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(HaveLen(6))
+		Ω(simplified[0]).Should(Equal(line[0]))
+		Ω(simplified[5]).Should(Equal(line[3]))
+		Ω(stats.OutputVertices).Should(Equal(6))
+	})
+
+	It("should densify lines too short to simplify", func() {
+		line := s2.Polyline(points([2]float64{0, 0}, [2]float64{0, 10}))
+		densified, err := geosimplification.SimplifyLine(line, 1, 0, true,
+			geosimplification.Densify(geosimplification.Densification{MaxLength: s1.Degree}),
+		)
+		Ω(err).Should(BeNil())
+		Ω(densified).Should(HaveLen(11))
+		Ω(densified[0]).Should(Equal(line[0]))
+		Ω(densified[10]).Should(Equal(line[1]))
+	})
+
+	It("should densify a loop without changing it", func() {
+		loop := s2.LoopFromPoints(points([2]float64{0, 0}, [2]float64{0, 5}, [2]float64{5, 5}, [2]float64{5, 0}))
+		loop.Normalize()
+		densified := geosimplification.DensifyLoop(loop, geosimplification.Densification{MaxLength: s1.Degree})
+		Ω(densified.Validate()).Should(BeNil())
+		Ω(densified.NumVertices()).Should(Equal(20))
+		Ω(densified.Area()).Should(BeNumerically("~", loop.Area(), 1e-12))
+	})
+})
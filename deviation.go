@@ -0,0 +1,197 @@
+package geosimplification
+
+import (
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// Mean radius of the earth, for turning angles into distances
+const EarthRadiusMetres = 6371010.0
+
+func AngleToMetres(angle s1.Angle) float64 {
+	return angle.Radians() * EarthRadiusMetres
+}
+
+func MetresToAngle(metres float64) s1.Angle {
+	return s1.Angle(metres / EarthRadiusMetres)
+}
+
+// How far a simplified shape deviates from the original
+// use AngleToMetres for distances on the earth
+type Report struct {
+	// The furthest any point of the original is from the simplified shape
+	DirectedHausdorff s1.Angle
+	// The larger of the directed Hausdorff distances, taken both ways
+	Hausdorff s1.Angle
+	// Like Hausdorff but respecting the order of the vertices
+	// a.k.a: the shortest leash walking both shapes in step
+	Frechet s1.Angle
+}
+
+// The furthest any point of `a`, along its edges as well as at its
+// vertices, is from the polyline `b`
+//
+// each edge of `a` is split until the furthest point is pinned down, to
+// within a millimetre or so on the earth. Distance along an edge is bounded
+// by its ends: it changes no faster than the edge runs, and over a short
+// edge it's never further from a fixed edge of `b` than the ends are
+func DirectedHausdorff(a, b s2.Polyline) s1.Angle {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	// a single point has no edges to index
+	if len(b) == 1 {
+		max := s1.Angle(0)
+		for _, point := range a {
+			if distance := point.Distance(b[0]); distance > max {
+				max = distance
+			}
+		}
+		return max
+	}
+
+	index := s2.NewShapeIndex()
+	index.Add(&b)
+	query := s2.NewClosestEdgeQuery(index, s2.NewClosestEdgeQueryOptions().MaxResults(1))
+	closest := func(point s2.Point) measured {
+		result := query.FindEdges(s2.NewMinDistanceToPointTarget(point))[0]
+		return measured{point: point, distance: result.Distance().Angle(), edge: int(result.EdgeID())}
+	}
+	toEdge := func(point s2.Point, edge int) s1.Angle {
+		return s2.DistanceFromSegment(point, b[edge], b[edge+1])
+	}
+
+	type span struct{ from, to measured }
+	spans := make([]span, 0, len(a))
+	max := s1.Angle(0)
+	prev := closest(a[0])
+	max = maxAngle(max, prev.distance)
+	for _, point := range a[1:] {
+		next := closest(point)
+		max = maxAngle(max, next.distance)
+		spans = append(spans, span{prev, next})
+		prev = next
+	}
+
+	for len(spans) > 0 {
+		s := spans[len(spans)-1]
+		spans = spans[:len(spans)-1]
+
+		length := s.from.point.Distance(s.to.point)
+		bound := minAngle(
+			(s.from.distance+s.to.distance+length)/2,
+			maxAngle(s.from.distance, toEdge(s.to.point, s.from.edge)),
+			maxAngle(toEdge(s.from.point, s.to.edge), s.to.distance),
+		)
+		if bound <= max+hausdorffTolerance {
+			continue
+		}
+		middle := closest(s2.Interpolate(0.5, s.from.point, s.to.point))
+		max = maxAngle(max, middle.distance)
+		spans = append(spans, span{s.from, middle}, span{middle, s.to})
+	}
+	return max
+}
+
+// How closely DirectedHausdorff pins down the furthest point, ~0.6mm
+const hausdorffTolerance = s1.Angle(1e-10)
+
+// A point and the edge of the other polyline closest to it
+type measured struct {
+	point    s2.Point
+	distance s1.Angle
+	edge     int
+}
+
+// The symmetric Hausdorff distance, the larger of the two directed distances
+func Hausdorff(a, b s2.Polyline) s1.Angle {
+	ab, ba := DirectedHausdorff(a, b), DirectedHausdorff(b, a)
+	if ab > ba {
+		return ab
+	}
+	return ba
+}
+
+// The discrete Fréchet distance between the vertices of `a` and `b`
+//
+// O(len(a) * len(b)) time, O(len(b)) space
+// see: Eiter & Mannila "Computing discrete Fréchet distance"
+func DiscreteFrechet(a, b s2.Polyline) s1.Angle {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	// only the previous row of the coupling table is ever needed
+	prev := make([]s1.Angle, len(b))
+	row := make([]s1.Angle, len(b))
+	for i := range a {
+		for j := range b {
+			distance := a[i].Distance(b[j])
+			switch {
+			case i == 0 && j == 0:
+				row[j] = distance
+			case i == 0:
+				row[j] = maxAngle(row[j-1], distance)
+			case j == 0:
+				row[j] = maxAngle(prev[j], distance)
+			default:
+				row[j] = maxAngle(minAngle(prev[j], prev[j-1], row[j-1]), distance)
+			}
+		}
+		prev, row = row, prev
+	}
+	return prev[len(b)-1]
+}
+
+func maxAngle(a, b s1.Angle) s1.Angle {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minAngle(angles ...s1.Angle) s1.Angle {
+	min := angles[0]
+	for _, angle := range angles[1:] {
+		if angle < min {
+			min = angle
+		}
+	}
+	return min
+}
+
+// A loop as a polyline, the first vertex is repeated to close it
+func ClosedPolyline(loop *s2.Loop) s2.Polyline {
+	polyline := make(s2.Polyline, 0, loop.NumVertices()+1)
+	polyline = append(polyline, loop.Vertices()...)
+	return append(polyline, loop.Vertex(0))
+}
+
+func LineReport(original, simplified s2.Polyline) Report {
+	return Report{
+		DirectedHausdorff: DirectedHausdorff(original, simplified),
+		Hausdorff:         Hausdorff(original, simplified),
+		Frechet:           DiscreteFrechet(original, simplified),
+	}
+}
+
+// Loops have no start, before measuring the Fréchet distance the simplified
+// loop is rotated to start at the vertex closest to the originals first vertex
+// both loops must have the same orientation
+func LoopReport(original, simplified *s2.Loop) Report {
+	originalLine := ClosedPolyline(original)
+
+	start := 0
+	for i, vertex := range simplified.Vertices() {
+		if vertex.Distance(original.Vertex(0)) < simplified.Vertex(start).Distance(original.Vertex(0)) {
+			start = i
+		}
+	}
+	simplifiedLine := make(s2.Polyline, 0, simplified.NumVertices()+1)
+	for i := 0; i <= simplified.NumVertices(); i++ {
+		simplifiedLine = append(simplifiedLine, simplified.Vertex(start+i))
+	}
+
+	return LineReport(originalLine, simplifiedLine)
+}
@@ -0,0 +1,95 @@
+package geosimplification_test
+
+import (
+	"math"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+var _ = Describe("Deviation unit tests", func() {
+	// a peak one degree north of the equator, and the line without it
+	original := *s2.PolylineFromLatLngs([]s2.LatLng{
+		s2.LatLngFromDegrees(0, 0),
+		s2.LatLngFromDegrees(1, 1),
+		s2.LatLngFromDegrees(0, 2),
+	})
+	simplified := *s2.PolylineFromLatLngs([]s2.LatLng{
+		s2.LatLngFromDegrees(0, 0),
+		s2.LatLngFromDegrees(0, 2),
+	})
+
+	It("should not deviate from itself", func() {
+		report := geosimplification.LineReport(original, original)
+		Ω(report).Should(Equal(geosimplification.Report{}))
+	})
+
+	It("should measure the distance to the simplified edges", func() {
+		Ω(geosimplification.DirectedHausdorff(original, simplified).Degrees()).Should(BeNumerically("~", 1, 1e-6))
+		// the middle of the simplified edge is half a diagonal from the peak's edges
+		Ω(geosimplification.DirectedHausdorff(simplified, original).Degrees()).Should(BeNumerically("~", math.Sqrt2/2, 1e-3))
+		Ω(geosimplification.Hausdorff(simplified, original).Degrees()).Should(BeNumerically("~", 1, 1e-6))
+	})
+
+	It("should measure between vertices too", func() {
+		straight := *s2.PolylineFromLatLngs([]s2.LatLng{s2.LatLngFromDegrees(0, 0), s2.LatLngFromDegrees(0, 10)})
+		bent := *s2.PolylineFromLatLngs([]s2.LatLng{
+			s2.LatLngFromDegrees(0, 0), s2.LatLngFromDegrees(3, 5), s2.LatLngFromDegrees(0, 10),
+		})
+		// brute force, along the straight edge
+		expected := s1.Angle(0)
+		for i := 0; i <= 10000; i++ {
+			point := s2.Interpolate(float64(i)/10000, straight[0], straight[1])
+			if projected, _ := bent.Project(point); point.Distance(projected) > expected {
+				expected = point.Distance(projected)
+			}
+		}
+		Ω(expected.Degrees()).Should(BeNumerically(">", 2.5))
+		Ω(geosimplification.DirectedHausdorff(straight, bent)).Should(BeNumerically("~", expected, 1e-6))
+		Ω(geosimplification.DirectedHausdorff(bent, straight).Degrees()).Should(BeNumerically("~", 3, 1e-6))
+	})
+
+	It("should measure the fréchet distance between vertices", func() {
+		// the peak has to be paired with one of the ends
+		expected := original[1].Distance(original[0])
+		Ω(geosimplification.DiscreteFrechet(original, simplified)).Should(BeNumerically("~", expected, 1e-9))
+		Ω(geosimplification.DiscreteFrechet(original, simplified)).Should(
+			BeNumerically(">=", geosimplification.Hausdorff(original, simplified)),
+		)
+	})
+
+	It("should convert angles to metres", func() {
+		Ω(geosimplification.AngleToMetres(s1.Degree)).Should(BeNumerically("~", 111195, 1))
+		Ω(geosimplification.MetresToAngle(111195).Degrees()).Should(BeNumerically("~", 1, 1e-5))
+	})
+
+	It("should report on simplification", func() {
+		report := geosimplification.Report{}
+		output, err := geosimplification.SimplifyLine(original, 1, 0, true,
+			geosimplification.WithReport(&report),
+		)
+		Ω(err).Should(BeNil())
+		Ω(output).Should(Equal(simplified))
+		Ω(report.DirectedHausdorff.Degrees()).Should(BeNumerically("~", 1, 1e-6))
+	})
+
+	It("should report on loops", func() {
+		loop := s2.LoopFromPoints(*s2.PolylineFromLatLngs([]s2.LatLng{
+			s2.LatLngFromDegrees(0, 0),
+			s2.LatLngFromDegrees(0, 1),
+			s2.LatLngFromDegrees(1, 1),
+			s2.LatLngFromDegrees(1.1, 0.5),
+			s2.LatLngFromDegrees(1, 0),
+		}))
+		report := geosimplification.Report{}
+		_, err := geosimplification.SimplifyLoop(loop, 1, 4, true,
+			geosimplification.WithReport(&report),
+		)
+		Ω(err).Should(BeNil())
+		Ω(report.Hausdorff.Degrees()).Should(BeNumerically("~", 0.1, 0.01))
+		Ω(report.Frechet).Should(BeNumerically(">=", report.Hausdorff))
+	})
+})
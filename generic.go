@@ -0,0 +1,109 @@
+package geosimplification
+
+import (
+	"github.com/golang/geo/r3"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+// The maths simplification needs for a point type P
+// implement this to simplify your own point types without copying them
+type Geometry[P any] interface {
+	// The area of the triangle abc, it's always positive
+	TriangleArea(a, b, c P) float64
+	// Do the edges a0a1 and b0b1 cross at a point interior to both
+	// sharing a vertex is not crossing
+	EdgesCross(a0, a1, b0, b1 P) bool
+	// Where the point sits in (up to) three dimensions, unused axes are 0
+	// used to build the bounding boxes for finding intersections
+	Coordinates(p P) r3.Vector
+}
+
+// Built in geometries for s2.Point, r2.Point and r3.Vector
+type (
+	Spherical = internal.Spherical
+	Planar    = internal.Planar
+	// Areas in 3D, crossings in plan view
+	Cartesian = internal.Cartesian
+)
+
+// SimplifyLine for any point type, see Geometry
+// options are specific to s2 and aren't supported
+func SimplifyLineOf[P any](
+	line []P,
+	geometry Geometry[P],
+	threshold float64,
+	minPointsToKeep int,
+	avoidIntersections bool,
+) (output []P, err error) {
+	// bail out if we don't have enough points
+	if len(line) <= minPointsToKeep || len(line) <= 2 {
+		return line[:], nil
+	}
+
+	pointList := internal.NewVertexList[P]()
+	for _, point := range line {
+		pointList.PushBack(internal.NewVertex(point))
+	}
+
+	if _, err := internal.VisvalingamWithGeometry[P](
+		geometry,
+		pointList,
+		threshold,
+		minPointsToKeep,
+		avoidIntersections,
+	); err != nil {
+		return nil, err
+	}
+
+	output = make([]P, 0, pointList.Len())
+	pointList.Do(func(point *internal.Vertex[P]) error {
+		output = append(output, point.Point)
+		return nil
+	})
+
+	return output, nil
+}
+
+// SimplifyLoop for any point type, see Geometry
+// the ring is open, its first point is not repeated at the end
+func SimplifyRingOf[P any](
+	ring []P,
+	geometry Geometry[P],
+	threshold float64,
+	minPointsToKeep int,
+	avoidIntersections bool,
+) (output []P, err error) {
+	// Require 4 points to keep the ring valid
+	// (double count start & finish)
+	if minPointsToKeep < 4 {
+		minPointsToKeep = 4
+	}
+
+	if len(ring) <= minPointsToKeep {
+		return ring[:], nil
+	}
+
+	root := internal.NewVertex(ring[0])
+	pointRing := internal.NewVertexRing(root)
+	for _, point := range ring[1:] {
+		pointRing.PushBack(internal.NewVertex(point))
+	}
+
+	if _, err := internal.VisvalingamWithGeometry[P](
+		geometry,
+		pointRing,
+		threshold,
+		minPointsToKeep,
+		avoidIntersections,
+	); err != nil {
+		return nil, err
+	}
+
+	output = make([]P, 0, pointRing.Len())
+	pointRing.Do(func(point *internal.Vertex[P]) error {
+		output = append(output, point.Point)
+		return nil
+	})
+
+	return output, nil
+}
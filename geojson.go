@@ -0,0 +1,275 @@
+package geosimplification
+
+import (
+	"math"
+	"sort"
+
+	"github.com/golang/geo/s2"
+)
+
+// A GeoJSON position, [longitude, latitude] in degrees
+type Position [2]float64
+
+func positionFromPoint(point s2.Point) Position {
+	latLng := s2.LatLngFromPoint(point)
+	return Position{latLng.Lng.Degrees(), latLng.Lat.Degrees()}
+}
+
+// Build a loop from a GeoJSON linear ring, closed or not
+// s2 edges are geodesics, so rings crossing the antimeridian need no help
+func LoopFromRing(ring []Position) *s2.Loop {
+	if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		ring = ring[:len(ring)-1]
+	}
+	// rings split at the antimeridian follow the pole, which is one point
+	// whatever the longitude, keep the first position of each run there
+	samePole := func(a, b Position) bool {
+		return math.Abs(a[1]) == 90 && a[1] == b[1]
+	}
+	for len(ring) > 1 && samePole(ring[len(ring)-1], ring[0]) {
+		ring = ring[:len(ring)-1]
+	}
+	points := make([]s2.Point, 0, len(ring))
+	for i, position := range ring {
+		if i > 0 && samePole(position, ring[i-1]) {
+			continue
+		}
+		points = append(points, s2.PointFromLatLng(s2.LatLngFromDegrees(position[1], position[0])))
+	}
+	return s2.LoopFromPoints(points)
+}
+
+// The latitude at which the geodesic ab meets the antimeridian
+// assumes the edge does cross it, and not the prime meridian
+func antimeridianLatitude(a, b s2.Point) float64 {
+	// the antimeridian lies in the plane Y = 0
+	t := a.Y / (a.Y - b.Y)
+	crossing := s2.Point{Vector: a.Add(b.Sub(a.Vector).Mul(t)).Normalize()}
+	return s2.LatLngFromPoint(crossing).Lat.Degrees()
+}
+
+// The GeoJSON MultiLineString coordinates for the polyline
+// per RFC 7946 the polyline is split wherever it crosses the antimeridian
+func PolylineToMultiLineString(polyline s2.Polyline) [][]Position {
+	if len(polyline) == 0 {
+		return nil
+	}
+
+	parts := [][]Position{{positionFromPoint(polyline[0])}}
+	for i := 1; i < len(polyline); i++ {
+		prev, next := positionFromPoint(polyline[i-1]), positionFromPoint(polyline[i])
+		if delta := next[0] - prev[0]; math.Abs(delta) > 180 {
+			lat := antimeridianLatitude(polyline[i-1], polyline[i])
+			// heading west over the antimeridian, from -180 to 180
+			side := -180.0
+			if delta < 0 {
+				side = 180
+			}
+			parts[len(parts)-1] = append(parts[len(parts)-1], Position{side, lat})
+			parts = append(parts, []Position{{-side, lat}})
+		}
+		parts[len(parts)-1] = append(parts[len(parts)-1], next)
+	}
+	return parts
+}
+
+// A vertex of a ring with its longitude unrolled, so the ring is continuous
+// `point` is nil for vertices we made up
+type unrolledVertex struct {
+	position Position
+	point    *s2.Point
+}
+
+// The GeoJSON MultiPolygon coordinates for the loop
+//
+// per RFC 7946 the loop is split wherever it crosses the antimeridian
+// loops containing a pole are closed along the pole, from 180 to -180
+// rings are CCW and closed (the first position is repeated)
+func LoopToMultiPolygon(loop *s2.Loop) [][][]Position {
+	if loop.NumVertices() == 0 || loop.IsEmpty() || loop.IsFull() {
+		return nil
+	}
+
+	vertices := loop.Vertices()
+	ring := make([]unrolledVertex, len(vertices))
+	for i := range vertices {
+		ring[i] = unrolledVertex{position: positionFromPoint(vertices[i]), point: &vertices[i]}
+		if i > 0 {
+			// never step more than 180 degrees, wrap instead
+			prev := ring[i-1].position[0]
+			ring[i].position[0] = prev + math.Remainder(ring[i].position[0]-prev, 360)
+		}
+	}
+
+	// Walking a loop around a pole leaves us a whole turn from where we started
+	first, last := ring[0].position, ring[len(ring)-1].position
+	winding := last[0] + math.Remainder(first[0]-last[0], 360) - first[0]
+	if math.Abs(winding) > 180 {
+		// CCW about the north pole is heading east
+		pole := 90.0
+		if winding < 0 {
+			pole = -90
+		}
+		end := first[0] + winding
+		ring = append(ring,
+			unrolledVertex{position: Position{end, first[1]}, point: ring[0].point},
+			unrolledVertex{position: Position{end, pole}},
+			unrolledVertex{position: Position{first[0], pole}},
+		)
+	}
+
+	minLng, maxLng := math.Inf(1), math.Inf(-1)
+	for _, vertex := range ring {
+		minLng = math.Min(minLng, vertex.position[0])
+		maxLng = math.Max(maxLng, vertex.position[0])
+	}
+
+	meridian := 0.0
+	switch {
+	case maxLng > 180:
+		meridian = 180
+	case minLng < -180:
+		meridian = -180
+	default:
+		return [][][]Position{{closeRing(ring)}}
+	}
+
+	pieces := splitRing(ring, meridian)
+	polygons := make([][][]Position, len(pieces))
+	for i, piece := range pieces {
+		// anything beyond the meridian wraps back around the world
+		shift := 0.0
+		for _, position := range piece {
+			if position[0] > 180 {
+				shift = -360
+			} else if position[0] < -180 {
+				shift = 360
+			}
+		}
+		for j := range piece {
+			piece[j][0] += shift
+		}
+		polygons[i] = [][]Position{piece}
+	}
+	return polygons
+}
+
+func closeRing(ring []unrolledVertex) []Position {
+	positions := make([]Position, 0, len(ring)+1)
+	for _, vertex := range ring {
+		// made up vertices can duplicate real ones
+		if len(positions) > 0 && positions[len(positions)-1] == vertex.position {
+			continue
+		}
+		positions = append(positions, vertex.position)
+	}
+	return append(positions, positions[0])
+}
+
+// A run of the ring on one side of the meridian
+// it starts and ends on the meridian
+type chain struct {
+	vertices []unrolledVertex
+	// the index of the crossing the chain ends on
+	end  int
+	used bool
+}
+
+// Split a (continuous) ring along the line longitude = meridian
+func splitRing(ring []unrolledVertex, meridian float64) [][]Position {
+	side := func(vertex unrolledVertex) int {
+		switch {
+		case vertex.position[0] < meridian:
+			return -1
+		case vertex.position[0] > meridian:
+			return 1
+		}
+		return 0
+	}
+
+	// put a vertex on the meridian wherever an edge crosses it
+	crossed := []unrolledVertex{}
+	for i := range ring {
+		a, b := ring[i], ring[(i+1)%len(ring)]
+		crossed = append(crossed, a)
+		if side(a)*side(b) >= 0 {
+			continue
+		}
+		var lat float64
+		if a.point != nil && b.point != nil {
+			lat = antimeridianLatitude(*a.point, *b.point)
+		} else {
+			t := (meridian - a.position[0]) / (b.position[0] - a.position[0])
+			lat = a.position[1] + t*(b.position[1]-a.position[1])
+		}
+		crossed = append(crossed, unrolledVertex{position: Position{meridian, lat}})
+	}
+
+	// find where the ring passes from one side to the other
+	// as opposed to touching the meridian and turning back
+	sideBefore := func(i int) int {
+		for j := 1; j < len(crossed); j++ {
+			if s := side(crossed[(i-j+len(crossed))%len(crossed)]); s != 0 {
+				return s
+			}
+		}
+		return 0
+	}
+	sideAfter := func(i int) int {
+		for j := 1; j < len(crossed); j++ {
+			if s := side(crossed[(i+j)%len(crossed)]); s != 0 {
+				return s
+			}
+		}
+		return 0
+	}
+	crossings := []int{}
+	for i, vertex := range crossed {
+		if side(vertex) == 0 && sideBefore(i)*sideAfter(i) < 0 {
+			crossings = append(crossings, i)
+		}
+	}
+	if len(crossings) < 2 {
+		return [][]Position{closeRing(ring)}
+	}
+
+	// chop the ring into chains between crossings
+	chains := map[int]*chain{}
+	for k, start := range crossings {
+		end := crossings[(k+1)%len(crossings)]
+		c := &chain{end: end}
+		for i := start; ; i = (i + 1) % len(crossed) {
+			c.vertices = append(c.vertices, crossed[i])
+			if i == end && len(c.vertices) > 1 {
+				break
+			}
+		}
+		chains[start] = c
+	}
+
+	// along the meridian the inside of the ring is between pairs of crossings
+	sorted := append([]int{}, crossings...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return crossed[sorted[i]].position[1] < crossed[sorted[j]].position[1]
+	})
+	pairs := map[int]int{}
+	for i := 0; i+1 < len(sorted); i += 2 {
+		pairs[sorted[i]], pairs[sorted[i+1]] = sorted[i+1], sorted[i]
+	}
+
+	pieces := [][]Position{}
+	for _, start := range crossings {
+		if chains[start].used {
+			continue
+		}
+		piece := []unrolledVertex{}
+		for current := chains[start]; current != nil && !current.used; {
+			current.used = true
+			piece = append(piece, current.vertices...)
+			// jump along the meridian to the next chain on this side
+			current = chains[pairs[current.end]]
+		}
+		pieces = append(pieces, closeRing(piece))
+	}
+	return pieces
+}
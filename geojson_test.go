@@ -0,0 +1,128 @@
+package geosimplification_test
+
+import (
+	"math"
+
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+// every position should be a valid GeoJSON position
+func expectWrapped(polygons [][][]geosimplification.Position) {
+	for _, polygon := range polygons {
+		for _, ring := range polygon {
+			Ω(ring[0]).Should(Equal(ring[len(ring)-1]))
+			for _, position := range ring {
+				Ω(position[0]).Should(BeNumerically(">=", -180))
+				Ω(position[0]).Should(BeNumerically("<=", 180))
+			}
+		}
+	}
+}
+
+var _ = Describe("GeoJSON conversion unit tests", func() {
+
+	It("should split polylines at the antimeridian", func() {
+		polyline := *s2.PolylineFromLatLngs([]s2.LatLng{
+			s2.LatLngFromDegrees(0, 179),
+			s2.LatLngFromDegrees(0, -179),
+		})
+		parts := geosimplification.PolylineToMultiLineString(polyline)
+		Ω(parts).Should(HaveLen(2))
+		Ω(parts[0][1][0]).Should(Equal(180.0))
+		Ω(parts[1][0][0]).Should(Equal(-180.0))
+		Ω(parts[0][1][1]).Should(BeNumerically("~", 0, 1e-9))
+	})
+
+	It("should leave polylines that don't cross alone", func() {
+		polyline := *s2.PolylineFromLatLngs([]s2.LatLng{
+			s2.LatLngFromDegrees(0, 10),
+			s2.LatLngFromDegrees(1, 11),
+		})
+		Ω(geosimplification.PolylineToMultiLineString(polyline)).Should(HaveLen(1))
+	})
+
+	Context("given a loop spanning the dateline", func() {
+		// a jagged box from 170E to 170W
+		latLngs := []s2.LatLng{}
+		for lng := 170.0; lng <= 190; lng += 1 {
+			latLngs = append(latLngs, s2.LatLngFromDegrees(-10+math.Mod(lng, 2)*0.01, lng))
+		}
+		for lng := 190.0; lng >= 170; lng -= 1 {
+			latLngs = append(latLngs, s2.LatLngFromDegrees(10+math.Mod(lng, 2)*0.01, lng))
+		}
+
+		It("should simplify without wrapping the world", func() {
+			loop := s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+			original := s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+			simplified, err := geosimplification.SimplifyLoop(loop, 1e-4, 0, true)
+			Ω(err).Should(BeNil())
+			Ω(simplified.Validate()).ShouldNot(HaveOccurred())
+			Ω(simplified.NumVertices()).Should(BeNumerically("<", original.NumVertices()))
+			Ω(simplified.Area()).Should(BeNumerically("~", original.Area(), original.Area()*0.01))
+			Ω(simplified.ContainsPoint(s2.PointFromLatLng(s2.LatLngFromDegrees(0, 180)))).Should(BeTrue())
+
+			polygons := geosimplification.LoopToMultiPolygon(simplified)
+			Ω(polygons).Should(HaveLen(2))
+			expectWrapped(polygons)
+		})
+
+		It("should round trip through GeoJSON rings", func() {
+			loop := s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+			polygons := geosimplification.LoopToMultiPolygon(loop)
+			area := 0.0
+			for _, polygon := range polygons {
+				area += geosimplification.LoopFromRing(polygon[0]).Area()
+			}
+			Ω(area).Should(BeNumerically("~", loop.Area(), loop.Area()*1e-6))
+		})
+	})
+
+	Context("given a loop containing a pole", func() {
+		// heading east around the north pole, it's on our left
+		latLngs := []s2.LatLng{}
+		for lng := -175.0; lng < 180; lng += 10 {
+			latLngs = append(latLngs, s2.LatLngFromDegrees(80+math.Mod(lng+175, 20)*0.01, lng))
+		}
+		northPole := s2.PointFromLatLng(s2.LatLngFromDegrees(90, 0))
+
+		It("should keep the pole when simplifying", func() {
+			loop := s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+			Ω(loop.ContainsPoint(northPole)).Should(BeTrue())
+			simplified, err := geosimplification.SimplifyLoop(loop, 1e-4, 0, true)
+			Ω(err).Should(BeNil())
+			Ω(simplified.Validate()).ShouldNot(HaveOccurred())
+			Ω(simplified.NumVertices()).Should(BeNumerically("<", len(latLngs)))
+			Ω(simplified.ContainsPoint(northPole)).Should(BeTrue())
+		})
+
+		It("should close the ring along the pole", func() {
+			loop := s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+			polygons := geosimplification.LoopToMultiPolygon(loop)
+			expectWrapped(polygons)
+
+			touchesPole := false
+			area := 0.0
+			for _, polygon := range polygons {
+				for _, position := range polygon[0] {
+					touchesPole = touchesPole || position[1] == 90
+				}
+				piece := geosimplification.LoopFromRing(polygon[0])
+				Ω(piece.Validate()).ShouldNot(HaveOccurred())
+				// the pole is one vertex, not one per longitude
+				atPole := 0
+				for i := 0; i < piece.NumVertices(); i++ {
+					if piece.Vertex(i).Distance(northPole) < 1e-12 {
+						atPole++
+					}
+				}
+				Ω(atPole).Should(BeNumerically("<=", 1))
+				area += piece.Area()
+			}
+			Ω(touchesPole).Should(BeTrue())
+			Ω(area).Should(BeNumerically("~", loop.Area(), loop.Area()*1e-6))
+		})
+	})
+})
@@ -0,0 +1,229 @@
+// Area preserving variant of Visvalingam
+//
+// rather than removing b from the triangle abc, two consecutive points b, c
+// in abcd are replaced with a single point e, placed so that the area of aed
+// is the same as the area of abcd
+//
+// see: Bose et al. "Area-preserving approximations of polygonal paths"
+// and Kronenfeld et al. "Simplification of polylines by segment collapse"
+package internal
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/dhconnelly/rtreego"
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/s2"
+)
+
+// Find the point `e` that replaces `point` and its next point
+// ok is false if there aren't enough neighbours to collapse the segment
+func SegmentCollapse(point *PointWithTriangle) (e s2.Point, ok bool) {
+	a, c := point.Prev(), point.Next()
+	if a == nil || c == nil {
+		return s2.Point{}, false
+	}
+	d := c.Next()
+	// in a ring with fewer than four points d wraps back around
+	if d == nil || d == a || d == point {
+		return s2.Point{}, false
+	}
+
+	projection := NewGnomonic(a.Point, point.Point, c.Point, d.Point)
+	pa := projection.Project(a.Point)
+	pb := projection.Project(point.Point)
+	pc := projection.Project(c.Point)
+	pd := projection.Project(d.Point)
+
+	// the shoelace formula, doubled
+	area := pa.Cross(pb) + pb.Cross(pc) + pc.Cross(pd) + pd.Cross(pa)
+
+	ad := pd.Sub(pa)
+	if ad.Norm() == 0 {
+		return s2.Point{}, false
+	}
+
+	// any point on the line parallel to ad, with the right offset preserves
+	// the area, choose the one closest to the middle of bc
+	mid := pb.Add(pc).Mul(0.5)
+	perpendicular := r2.Point{X: ad.Y, Y: -ad.X}
+	offset := (area - mid.Sub(pa).Cross(ad)) / ad.Dot(ad)
+	return projection.Unproject(mid.Add(perpendicular.Mul(offset))), true
+}
+
+// The area displaced by collapsing `point` and its next point
+func CollapseArea(point *PointWithTriangle) float64 {
+	e, ok := SegmentCollapse(point)
+	if !ok {
+		return math.Inf(1)
+	}
+	a, c := point.Prev(), point.Next()
+	d := c.Next()
+	return s2.PointArea(a.Point, point.Point, e) +
+		s2.PointArea(point.Point, c.Point, e) +
+		s2.PointArea(c.Point, d.Point, e)
+}
+
+// returns the first intersection it finds when collapsing `point`
+// and its next point into `e`
+func CollapseCreatesIntersection(
+	rtree *rtreego.Rtree,
+	point *PointWithTriangle,
+	e s2.Point,
+) []s2.Edge {
+	a, c := point.Prev(), point.Next()
+	d := c.Next()
+
+	bounds, err := BuildRTreeRect(a.Point, point.Point, c.Point, d.Point, e)
+	if err != nil {
+		return nil
+	}
+	proposedEdges := []s2.Edge{{a.Point, e}, {e, d.Point}}
+
+	// the edges ab, bc and cd are all being replaced
+	replaced := func(x, y *PointWithTriangle) bool {
+		return x == point || x == c || y == point || y == c
+	}
+
+	for _, candidateI := range rtree.SearchIntersect(bounds) {
+		candidate := candidateI.(*PointWithTriangle)
+		for _, neighbour := range []*PointWithTriangle{candidate.Prev(), candidate.Next()} {
+			if neighbour == nil || replaced(candidate, neighbour) {
+				continue
+			}
+			edge := s2.Edge{candidate.Point, neighbour.Point}
+			for _, proposedEdge := range proposedEdges {
+				if EdgesCross(proposedEdge, edge) {
+					return []s2.Edge{proposedEdge, edge}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Like Visvalingam, but collapsing segments rather than removing points
+// each point's Area is the area displaced by collapsing it and its next point
+//
+// `maxAreaChange` bounds how far (in steradians) the total area may drift
+// the collapse is exact in a gnomonic projection, not on the sphere
+func AreaPreserving(
+	pointList VertexCollection,
+	threshold float64,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	maxAreaChange float64,
+) (stats Stats, err error) {
+
+	minHeap := &PointWithTriangleHeap{}
+	heap.Init(minHeap)
+
+	minBranchFactor := 25
+	maxBranchFactor := 50
+	rtree := rtreego.NewTree(3, minBranchFactor, maxBranchFactor)
+
+	if err = pointList.Do(func(point *PointWithTriangle) error {
+		point.Area = CollapseArea(point)
+		point.BBox, err = TriangleBbox(point)
+		if err != nil {
+			return err
+		}
+		heap.Push(minHeap, point)
+		rtree.Insert(point)
+		return nil
+	}); err != nil {
+		return stats, err
+	}
+
+	maxArea := 0.0
+	totalChange := 0.0
+	intersecting := []*PointWithTriangle{}
+	for elementI := heap.Pop(minHeap); elementI != nil; elementI = heap.Pop(minHeap) {
+		head := elementI.(*PointWithTriangle)
+
+		// see Visvalingam, a collapse can't be cheaper than an earlier one
+		if head.Area < maxArea {
+			head.Area = maxArea
+		} else {
+			maxArea = head.Area
+		}
+
+		e, ok := SegmentCollapse(head)
+		if !ok {
+			continue
+		}
+		a, c := head.Prev(), head.Next()
+		d := c.Next()
+		change := s2.SignedArea(a.Point, e, d.Point) -
+			s2.SignedArea(a.Point, head.Point, c.Point) -
+			s2.SignedArea(a.Point, c.Point, d.Point)
+
+		if avoidIntersections && CollapseCreatesIntersection(rtree, head, e) != nil {
+			intersecting = append(intersecting, head)
+			stats.Intersections++
+			continue
+		}
+		if math.Abs(totalChange+change) > maxAreaChange {
+			intersecting = append(intersecting, head)
+			stats.Vetoed++
+			continue
+		}
+
+		// collapses may have removed points we set aside
+		for _, intersectingElement := range intersecting {
+			if intersectingElement.list != nil {
+				heap.Push(minHeap, intersectingElement)
+			}
+		}
+		intersecting = []*PointWithTriangle{}
+
+		if head.Area >= threshold {
+			break
+		}
+
+		// remove all triangles touched from the rtree
+		for _, point := range []*PointWithTriangle{a, head, c, d} {
+			rtree.Delete(point)
+		}
+
+		// c is gone, head takes the place of e
+		if c.HeapIndex > -1 {
+			heap.Remove(minHeap, c.HeapIndex)
+		}
+		pointList.Remove(c)
+		head.Point = e
+		totalChange += change
+		stats.EffectiveArea = head.Area
+
+		if pointList.Len() <= minPointsToKeep {
+			break
+		}
+
+		// keep the rtree up to date
+		for _, point := range []*PointWithTriangle{a, head, d} {
+			point.BBox, err = TriangleBbox(point)
+			if err != nil {
+				return stats, err
+			}
+			rtree.Insert(point)
+		}
+
+		// any segment whose neighbourhood included b or c has a new collapse
+		affected := []*PointWithTriangle{a, head, d}
+		if beforeA := a.Prev(); beforeA != nil && beforeA != d {
+			affected = append(affected, beforeA)
+		}
+		for _, point := range affected {
+			point.Area = CollapseArea(point)
+			if point.HeapIndex > -1 {
+				heap.Fix(minHeap, point.HeapIndex)
+			} else if point == head {
+				heap.Push(minHeap, point)
+			}
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,159 @@
+// Cleaning rings, removing spikes and cutting off sliver peninsulas
+package internal
+
+import (
+	"math"
+
+	"github.com/dhconnelly/rtreego"
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// Where to cut the ring from `key`, every vertex between is removed
+// nil (or key.Next()) leaves the ring as it is
+type Cut func(key *PointWithTriangle) *PointWithTriangle
+
+// Cut the ring from every vertex, over and over until nothing more is cut
+//
+// vertices are removed one at a time with the same intersection checks and
+// constraints as Visvalingam, if one is rejected the cut stops there
+func CutRing(
+	ring *PointWithTriangleRing,
+	cut Cut,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	constraints ...Constraint,
+) (Stats, error) {
+	r, err := newRemover(ring, avoidIntersections, constraints)
+	if err != nil {
+		return Stats{}, err
+	}
+	for changed := true; changed; {
+		changed = false
+		keys := make([]*PointWithTriangle, 0, ring.Len())
+		ring.Do(func(point *PointWithTriangle) error {
+			keys = append(keys, point)
+			return nil
+		})
+		for _, key := range keys {
+			// removed by an earlier cut
+			if key.list == nil {
+				continue
+			}
+			target := cut(key)
+			if target == nil {
+				continue
+			}
+			for point := key.Next(); point != target; point = key.Next() {
+				if ring.Len() <= minPointsToKeep {
+					return r.stats, nil
+				}
+				ok, err := r.remove(point)
+				if err != nil {
+					return r.stats, err
+				}
+				if !ok {
+					break
+				}
+				changed = true
+			}
+		}
+	}
+	return r.stats, nil
+}
+
+// Cut off the vertex after key if it's a spike, the angle there is less
+// than `maxAngle` and an edge either side is at least `minLength` long
+func Spikes(maxAngle, minLength s1.Angle) Cut {
+	return func(key *PointWithTriangle) *PointWithTriangle {
+		tip := key.Next()
+		after := tip.Next()
+		if after == key || tip.Point == key.Point || tip.Point == after.Point {
+			return nil
+		}
+		if s2.Angle(key.Point, tip.Point, after.Point) >= maxAngle {
+			return nil
+		}
+		if key.Point.Distance(tip.Point) < minLength && tip.Point.Distance(after.Point) < minLength {
+			return nil
+		}
+		return after
+	}
+}
+
+// A vertex of the ring, indexed by its position
+type ringVertex struct {
+	*PointWithTriangle
+	rect *rtreego.Rect
+}
+
+func (v ringVertex) Bounds() *rtreego.Rect {
+	return v.rect
+}
+
+// Cut off peninsulas narrower than `width`: runs of the ring that leave
+// it and come back within `width` of where they left, enclosing a sliver
+// less than `width` wide on average (2 area / perimeter)
+// the widest cut from a key wins, a run is at most half the ring
+func Peninsulas(ring *PointWithTriangleRing, width s1.Angle) (Cut, error) {
+	rtree := rtreego.NewTree(3, 25, 50)
+	position := map[*PointWithTriangle]int{}
+	err := ring.Do(func(point *PointWithTriangle) error {
+		rect, err := boxAround(point.Point, width)
+		if err != nil {
+			return err
+		}
+		position[point] = len(position)
+		rtree.Insert(ringVertex{PointWithTriangle: point, rect: rect})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	n := len(position)
+
+	return func(key *PointWithTriangle) *PointWithTriangle {
+		rect, err := boxAround(key.Point, width)
+		if err != nil {
+			return nil
+		}
+		var target *PointWithTriangle
+		furthest := 1
+		for _, candidateI := range rtree.SearchIntersect(rect) {
+			candidate := candidateI.(ringVertex).PointWithTriangle
+			ahead := (position[candidate] - position[key] + n) % n
+			if candidate.list == nil || ahead <= furthest || ahead > n/2 {
+				continue
+			}
+			if key.Point.Distance(candidate.Point) >= width {
+				continue
+			}
+			if sliverWidth(key, candidate) < width {
+				target, furthest = candidate, ahead
+			}
+		}
+		return target
+	}, nil
+}
+
+// A box `radius` either side of point
+func boxAround(point s2.Point, radius s1.Angle) (*rtreego.Rect, error) {
+	r := math.Max(radius.Radians(), 1e-12)
+	return rtreego.NewRect(
+		rtreego.Point{point.X - r, point.Y - r, point.Z - r},
+		[]float64{2 * r, 2 * r, 2 * r},
+	)
+}
+
+// How wide, on average, the ring from `from` to `to` (closed) is
+func sliverWidth(from, to *PointWithTriangle) s1.Angle {
+	area, perimeter := 0.0, to.Point.Distance(from.Point)
+	for point := from; point != to; point = point.Next() {
+		area += s2.SignedArea(from.Point, point.Point, point.Next().Point)
+		perimeter += point.Point.Distance(point.Next().Point)
+	}
+	if perimeter == 0 {
+		return 0
+	}
+	return s1.Angle(2 * math.Abs(area) / perimeter.Radians())
+}
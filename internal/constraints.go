@@ -0,0 +1,182 @@
+// Constraints veto the removal of points from a shape
+// on top of the intersection checks Visvalingam already does
+package internal
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dhconnelly/rtreego"
+	"github.com/golang/geo/s2"
+)
+
+// A Constraint can veto the removal of a point, vetoed points are treated
+// the same as intersecting points: they're retried once another point
+// has been removed (and their triangle may have changed)
+type ConstraintOf[P any] interface {
+	Allows(point *Vertex[P]) bool
+}
+
+type Constraint = ConstraintOf[s2.Point]
+
+// Constraints that keep state can ask to be told when a point
+// they allowed is actually removed
+type RemovalObserverOf[P any] interface {
+	Removed(point *Vertex[P])
+}
+
+type RemovalObserver = RemovalObserverOf[s2.Point]
+
+func allowed[P any](constraints []ConstraintOf[P], point *Vertex[P]) bool {
+	for _, constraint := range constraints {
+		if !constraint.Allows(point) {
+			return false
+		}
+	}
+	return true
+}
+
+// Called before `point` leaves the collection, its neighbours are still intact
+func removed[P any](constraints []ConstraintOf[P], point *Vertex[P]) {
+	for _, constraint := range constraints {
+		if observer, ok := constraint.(RemovalObserverOf[P]); ok {
+			observer.Removed(point)
+		}
+	}
+}
+
+// Report if `p` falls inside (or on the boundary of) the triangle abc
+// the triangle may be wound either way
+func TriangleContains(a, b, c, p s2.Point) bool {
+	orientation := s2.RobustSign(a, b, c)
+	// a degenerate triangle has no area, nothing can be inside it
+	if orientation == s2.Indeterminate {
+		return false
+	}
+	for _, sign := range []s2.Direction{
+		s2.RobustSign(a, b, p),
+		s2.RobustSign(b, c, p),
+		s2.RobustSign(c, a, p),
+	} {
+		if sign == -orientation {
+			return false
+		}
+	}
+	return true
+}
+
+// Wrap s2.Point to provide bounding box information about it
+type boundablePoint struct {
+	s2.Point
+	// Cached for efficiency
+	rect *rtreego.Rect
+}
+
+// fufill the rtreego.Spatial interface
+func (p boundablePoint) Bounds() *rtreego.Rect {
+	return p.rect
+}
+
+// Points that must stay on the same side of the shape
+//
+// removing the point b in the triangle abc replaces the edges ab, bc with ac
+// the only area that changes sides is the triangle itself, so any
+// preserved point inside that triangle vetoes the removal
+type PreservedPoints struct {
+	rtree *rtreego.Rtree
+}
+
+func NewPreservedPoints(points ...s2.Point) (*PreservedPoints, error) {
+	minBranchFactor := 25
+	maxBranchFactor := 50
+	rtree := rtreego.NewTree(3, minBranchFactor, maxBranchFactor)
+
+	for i, point := range points {
+		rect, err := BuildRTreePoint(point)
+		if err != nil {
+			return nil, fmt.Errorf("point `%d`: %s", i, err.Error())
+		}
+		rtree.Insert(boundablePoint{Point: point, rect: rect})
+	}
+
+	return &PreservedPoints{rtree: rtree}, nil
+}
+
+func (p *PreservedPoints) Allows(point *PointWithTriangle) bool {
+	// the start and end of a polyline are never removed
+	if point.Prev() == nil || point.Next() == nil {
+		return true
+	}
+
+	// for efficiency we only look at preserved points in the triangles
+	// bounding box. The box of its vertices isn't enough, the triangle
+	// bulges out of it towards the surface, so it's grown to cover that
+	prev, next := point.Prev().Point, point.Next().Point
+	bounds, err := BuildPaddedRTreeRect(triangleBulge(prev, point.Point, next), prev, point.Point, next)
+	if err != nil {
+		// identical vertices, there's nothing inside the triangle
+		return true
+	}
+	for _, candidateI := range p.rtree.SearchIntersect(bounds) {
+		candidate := candidateI.(boundablePoint)
+		if TriangleContains(prev, point.Point, next, candidate.Point) {
+			return false
+		}
+	}
+	return true
+}
+
+// How far the spherical triangle abc reaches beyond the flat one
+//
+// a point q on the flat triangle is projected to q/|q|, 1 - |q| away
+// and with c the longest chord |q|² >= 1 - c²/3, the least at the centroid
+// of an equilateral triangle. A little more is added for rounding, since
+// the rtree doesn't count boxes that only touch as intersecting
+func triangleBulge(a, b, c s2.Point) float64 {
+	chord := math.Max(a.Sub(b.Vector).Norm2(), math.Max(b.Sub(c.Vector).Norm2(), c.Sub(a.Vector).Norm2()))
+	if chord >= 3 {
+		return 1
+	}
+	return 1 - math.Sqrt(1-chord/3) + 1e-12
+}
+
+// Veto removing points that turn in the `Forbidden` direction
+//
+// for a CCW loop removing a CCW (convex) point cuts its triangle out of the loop
+// and removing a CW (reflex) point adds its triangle to the loop
+// forbidding one or the other means the loop can only ever shrink or grow
+type TurnConstraint struct {
+	Forbidden s2.Direction
+}
+
+func (t TurnConstraint) Allows(point *PointWithTriangle) bool {
+	if point.Prev() == nil || point.Next() == nil {
+		return true
+	}
+	return s2.RobustSign(point.Prev().Point, point.Point, point.Next().Point) != t.Forbidden
+}
+
+// Veto removing points once the area of a loop has changed by `Max` steradians
+//
+// removing the point b in the CCW triangle abc cuts the triangle out of the loop
+// removing it from a CW triangle adds the triangle to the loop
+type AreaBudget struct {
+	Max float64
+	// the signed change in area so far
+	Change float64
+}
+
+func areaChange(point *PointWithTriangle) float64 {
+	if point.Prev() == nil || point.Next() == nil {
+		return 0
+	}
+	return -s2.SignedArea(point.Prev().Point, point.Point, point.Next().Point)
+}
+
+func (a *AreaBudget) Allows(point *PointWithTriangle) bool {
+	return math.Abs(a.Change+areaChange(point)) <= a.Max
+}
+
+func (a *AreaBudget) Removed(point *PointWithTriangle) {
+	a.Change += areaChange(point)
+}
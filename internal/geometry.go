@@ -0,0 +1,133 @@
+// The maths Visvalingam depends on, so the same heap and rtree
+// code can run on the sphere, on a plane, or in cartesian space
+package internal
+
+import (
+	"math"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	"github.com/golang/geo/s2"
+)
+
+type Geometry[P any] interface {
+	// The area of the triangle abc, it's always positive
+	TriangleArea(a, b, c P) float64
+	// Do the edges a0a1 and b0b1 cross at a point interior to both
+	// sharing a vertex is not crossing
+	EdgesCross(a0, a1, b0, b1 P) bool
+	// Where the point sits in (up to) three dimensions
+	// used to build the bounding boxes indexed by the rtree
+	Coordinates(p P) r3.Vector
+}
+
+// Like s2.Edge, for any point type
+type Edge[P any] struct {
+	V0, V1 P
+}
+
+// Points on the unit sphere, edges are geodesics
+type Spherical struct{}
+
+func (Spherical) TriangleArea(a, b, c s2.Point) float64 {
+	// Note: under the covers this will defer to GirardArea if possible
+	// no need to swap it out
+	return s2.PointArea(a, b, c)
+}
+
+func (Spherical) EdgesCross(a0, a1, b0, b1 s2.Point) bool {
+	return EdgesCross(s2.Edge{V0: a0, V1: a1}, s2.Edge{V0: b0, V1: b1})
+}
+
+func (Spherical) Coordinates(p s2.Point) r3.Vector {
+	return p.Vector
+}
+
+// twice the signed area of abc, positive if abc is CCW
+func orient(a, b, c r2.Point) float64 {
+	return b.Sub(a).Cross(c.Sub(a))
+}
+
+// Mirror CrossingSign, only a crossing strictly inside both edges counts
+func planarEdgesCross(a0, a1, b0, b1 r2.Point) bool {
+	return orient(a0, a1, b0)*orient(a0, a1, b1) < 0 &&
+		orient(b0, b1, a0)*orient(b0, b1, a1) < 0
+}
+
+// Points on a plane
+// e.g: projected coordinates like UTM or state plane
+type Planar struct{}
+
+func (Planar) TriangleArea(a, b, c r2.Point) float64 {
+	return math.Abs(orient(a, b, c)) / 2
+}
+
+func (Planar) EdgesCross(a0, a1, b0, b1 r2.Point) bool {
+	return planarEdgesCross(a0, a1, b0, b1)
+}
+
+func (Planar) Coordinates(p r2.Point) r3.Vector {
+	return r3.Vector{X: p.X, Y: p.Y}
+}
+
+// Points in cartesian space, e.g: a line with elevations
+// areas are measured in 3D, but since lines in 3D (almost) never cross
+// crossings are measured in plan view, on the XY plane
+type Cartesian struct{}
+
+func (Cartesian) TriangleArea(a, b, c r3.Vector) float64 {
+	return b.Sub(a).Cross(c.Sub(a)).Norm() / 2
+}
+
+func (Cartesian) EdgesCross(a0, a1, b0, b1 r3.Vector) bool {
+	plan := func(v r3.Vector) r2.Point {
+		return r2.Point{X: v.X, Y: v.Y}
+	}
+	return planarEdgesCross(plan(a0), plan(a1), plan(b0), plan(b1))
+}
+
+// indexed in plan view too, edges crossing at different heights
+// must still meet in the rtree
+func (Cartesian) Coordinates(p r3.Vector) r3.Vector {
+	return r3.Vector{X: p.X, Y: p.Y}
+}
+
+// Ranks vertices by `Rank` of their neighbourhood, rather than the area
+// of their triangle. Bounding boxes and crossings are left to Geometry
+type Ranked[P any] struct {
+	Geometry[P]
+	Rank func(prev, current, next P) float64
+}
+
+func (r Ranked[P]) TriangleArea(a, b, c P) float64 {
+	return r.Rank(a, b, c)
+}
+
+// A Geometry whose ranks depend on more than the vertex's triangle
+// see WindowRanked
+type Windowed[P any] interface {
+	Geometry[P]
+	// how many live vertices either side a rank looks at
+	Reach() int
+	RankWindow(window []P, at int) float64
+}
+
+// Ranks vertices by `Rank` of the live vertices up to `Reach` either side
+// of them, window[at] is the vertex being ranked
+type WindowRanked[P any] struct {
+	Geometry[P]
+	Window int
+	Rank   func(window []P, at int) float64
+}
+
+func (r WindowRanked[P]) Reach() int {
+	return r.Window
+}
+
+func (r WindowRanked[P]) RankWindow(window []P, at int) float64 {
+	return r.Rank(window, at)
+}
+
+func (r WindowRanked[P]) TriangleArea(a, b, c P) float64 {
+	return r.Rank([]P{a, b, c}, 1)
+}
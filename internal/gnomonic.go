@@ -0,0 +1,39 @@
+// A gnomonic projection maps great circles to straight lines
+// handy for doing planar geometry over a small neighbourhood of points
+package internal
+
+import (
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	"github.com/golang/geo/s2"
+)
+
+type Gnomonic struct {
+	// the tangent point, and two axes in the tangent plane
+	center, u, v r3.Vector
+}
+
+// Project about the centroid of `points`
+func NewGnomonic(points ...s2.Point) Gnomonic {
+	center := r3.Vector{}
+	for _, point := range points {
+		center = center.Add(point.Vector)
+	}
+	center = center.Normalize()
+	u := center.Ortho()
+	return Gnomonic{
+		center: center,
+		u:      u,
+		v:      center.Cross(u),
+	}
+}
+
+// Only valid for points in the same hemisphere as the center
+func (g Gnomonic) Project(point s2.Point) r2.Point {
+	q := point.Mul(1 / point.Dot(g.center))
+	return r2.Point{X: q.Dot(g.u), Y: q.Dot(g.v)}
+}
+
+func (g Gnomonic) Unproject(point r2.Point) s2.Point {
+	return s2.Point{Vector: g.center.Add(g.u.Mul(point.X)).Add(g.v.Mul(point.Y)).Normalize()}
+}
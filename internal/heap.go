@@ -3,20 +3,30 @@
 // a.k.a: the least significant point
 package internal
 
-type PointWithTriangleHeap struct {
-	indexed []*PointWithTriangle
+import "github.com/golang/geo/s2"
+
+type VertexHeap[P any] struct {
+	indexed []*Vertex[P]
 }
 
-func (h PointWithTriangleHeap) Len() int {
+type PointWithTriangleHeap = VertexHeap[s2.Point]
+
+func (h VertexHeap[P]) Len() int {
 	return len(h.indexed)
 }
 
-// Our heap is sorted by area
-func (h PointWithTriangleHeap) Less(i, j int) bool {
-	return h.indexed[i].Area < h.indexed[j].Area
+// Our heap is sorted by area, equal areas (common in gridded or
+// symmetric data) by Index, so the vertex added first goes first
+// every vertex is ordered, the result doesn't depend on the heap's layout
+func (h VertexHeap[P]) Less(i, j int) bool {
+	a, b := h.indexed[i], h.indexed[j]
+	if a.Area != b.Area {
+		return a.Area < b.Area
+	}
+	return a.Index < b.Index
 }
 
-func (h PointWithTriangleHeap) Swap(i, j int) {
+func (h VertexHeap[P]) Swap(i, j int) {
 	// On removal the heap interface does Swap(0, len(heap)-1)
 	// which if the heap is empty will trigger an index out of range error
 	if i < 0 || j < 0 {
@@ -27,19 +37,19 @@ func (h PointWithTriangleHeap) Swap(i, j int) {
 	h.indexed[i], h.indexed[j] = h.indexed[j], h.indexed[i]
 }
 
-func (heap *PointWithTriangleHeap) Push(value interface{}) {
-	point := value.(*PointWithTriangle)
+func (heap *VertexHeap[P]) Push(value interface{}) {
+	point := value.(*Vertex[P])
 	// if there's nothing in the array this will be at the 0th position
 	// off by one errors begone!
 	point.HeapIndex = heap.Len()
 	heap.indexed = append(heap.indexed, point)
 }
 
-func (heap *PointWithTriangleHeap) Pop() (tailI interface{}) {
+func (heap *VertexHeap[P]) Pop() (tailI interface{}) {
 	if heap.Len() == 0 {
 		return nil
 	}
-	var tail *PointWithTriangle
+	var tail *Vertex[P]
 	tail, heap.indexed = heap.indexed[heap.Len()-1], heap.indexed[:heap.Len()-1]
 
 	tail.HeapIndex = -1
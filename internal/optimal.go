@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// Imai-Iri, the fewest vertices of `points` such that every vertex left out
+// is within `tolerance` of the edge that replaces it
+//
+// the shortcut graph has an edge i->j wherever the vertices between are
+// within tolerance of the edge ij, and `allowed(i, j)`. A breadth first
+// search from the first vertex finds the shortest path to the last
+// shortcuts are tested as they're reached, O(n³) time and O(n) memory
+// returns the indices of the vertices kept
+func ImaiIri(points []s2.Point, tolerance s1.Angle, allowed func(i, j int) bool) []int {
+	if len(points) < 3 {
+		indices := make([]int, len(points))
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices
+	}
+
+	last := len(points) - 1
+	from := make([]int, len(points))
+	for i := range from {
+		from[i] = -1
+	}
+	from[0] = 0
+	queue := []int{0}
+	for len(queue) > 0 && from[last] < 0 {
+		i := queue[0]
+		queue = queue[1:]
+		// furthest first, so the search reaches the end sooner
+		for j := last; j > i; j-- {
+			if from[j] >= 0 {
+				continue
+			}
+			if j == i+1 || (withinTolerance(points, i, j, tolerance) && allowed(i, j)) {
+				from[j] = i
+				queue = append(queue, j)
+			}
+		}
+	}
+
+	path := []int{last}
+	for i := last; i != 0; i = from[i] {
+		path = append(path, from[i])
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+func withinTolerance(points []s2.Point, i, j int, tolerance s1.Angle) bool {
+	for k := i + 1; k < j; k++ {
+		if s2.DistanceFromSegment(points[k], points[i], points[j]) > tolerance {
+			return false
+		}
+	}
+	return true
+}
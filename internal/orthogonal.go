@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"errors"
+	"math"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// The dominant orientation of a building, its edges mostly run along
+// or across it. Measured in a gnomonic projection about the building,
+// which keeps right angles right over a building's few metres
+type Orthogonal struct {
+	projection Gnomonic
+	// along and across the building, unit vectors in the projection
+	along, across r2.Point
+	tolerance     float64
+}
+
+// The orientation of the loops, edges within `tolerance` of it are
+// squared up. Long edges count for more than short ones
+func NewOrthogonal(tolerance s1.Angle, loops ...[]s2.Point) *Orthogonal {
+	all := []s2.Point{}
+	for _, loop := range loops {
+		all = append(all, loop...)
+	}
+	o := &Orthogonal{projection: NewGnomonic(all...), tolerance: tolerance.Radians()}
+
+	// edge directions modulo 90 degrees, by averaging 4θ
+	sum := r2.Point{}
+	for _, loop := range loops {
+		for i := range loop {
+			d := o.projection.Project(loop[(i+1)%len(loop)]).Sub(o.projection.Project(loop[i]))
+			theta := math.Atan2(d.Y, d.X)
+			sum = sum.Add(r2.Point{X: math.Cos(4 * theta), Y: math.Sin(4 * theta)}.Mul(d.Norm()))
+		}
+	}
+	phi := math.Atan2(sum.Y, sum.X) / 4
+	o.along = r2.Point{X: math.Cos(phi), Y: math.Sin(phi)}
+	o.across = o.along.Ortho()
+	return o
+}
+
+const (
+	free = iota - 1
+	along
+	across
+)
+
+// Whether the edge ab runs along or across the building, or neither
+func (o *Orthogonal) class(a, b s2.Point) int {
+	d := o.projection.Project(b).Sub(o.projection.Project(a))
+	theta := math.Atan2(d.Dot(o.across), d.Dot(o.along))
+	quarter := math.Round(theta / (math.Pi / 2))
+	if math.Abs(theta-quarter*math.Pi/2) > o.tolerance {
+		return free
+	}
+	if int(math.Abs(quarter))%2 == 0 {
+		return along
+	}
+	return across
+}
+
+// Veto removing a corner between two square edges, unless the edge left
+// is square too. e.g: a small step in a wall may go, a corner may not
+func (o *Orthogonal) Allows(point *PointWithTriangle) bool {
+	prev, next := point.Prev(), point.Next()
+	if prev == nil || next == nil {
+		return true
+	}
+	if o.class(prev.Point, point.Point) == free || o.class(point.Point, next.Point) == free {
+		return true
+	}
+	return o.class(prev.Point, next.Point) != free
+}
+
+// Square up a ring (first point not repeated)
+//
+// vertices between edges running the same way are dropped, then edges
+// running along (or across) the building are made exactly so, each moved
+// to the average of its ends. Corners between them become right angles
+func (o *Orthogonal) Square(ring []s2.Point) ([]s2.Point, error) {
+	ring = append([]s2.Point{}, ring...)
+	for merged := true; merged && len(ring) > 3; {
+		merged = false
+		for i := range ring {
+			prev, next := ring[(i-1+len(ring))%len(ring)], ring[(i+1)%len(ring)]
+			if class := o.class(prev, ring[i]); class != free && class == o.class(ring[i], next) {
+				ring = append(ring[:i], ring[i+1:]...)
+				merged = true
+				break
+			}
+		}
+	}
+
+	// in the building's frame, x along and y across
+	frame := make([]r2.Point, len(ring))
+	for i, point := range ring {
+		p := o.projection.Project(point)
+		frame[i] = r2.Point{X: p.Dot(o.along), Y: p.Dot(o.across)}
+	}
+	classes := make([]int, len(ring))
+	for i := range ring {
+		classes[i] = o.class(ring[i], ring[(i+1)%len(ring)])
+	}
+
+	squared := make([]s2.Point, 0, len(ring))
+	for i := range ring {
+		point := frame[i]
+		// the edges either side, edge i runs from vertex i to i+1
+		for _, edge := range []int{(i - 1 + len(ring)) % len(ring), i} {
+			a, b := frame[edge], frame[(edge+1)%len(ring)]
+			switch classes[edge] {
+			case along:
+				point.Y = (a.Y + b.Y) / 2
+			case across:
+				point.X = (a.X + b.X) / 2
+			}
+		}
+		vertex := o.projection.Unproject(o.along.Mul(point.X).Add(o.across.Mul(point.Y)))
+		if len(squared) > 0 && squared[len(squared)-1] == vertex {
+			continue
+		}
+		squared = append(squared, vertex)
+	}
+	if len(squared) > 1 && squared[0] == squared[len(squared)-1] {
+		squared = squared[:len(squared)-1]
+	}
+	if len(squared) < 3 {
+		return nil, errors.New("loop collapsed when squared")
+	}
+	return squared, nil
+}
@@ -0,0 +1,68 @@
+// Concrete implementation of VertexCollection
+// for traversing several lines and loops as one shape
+package internal
+
+// The parts of a multi-part shape, e.g: the loops of a multipolygon
+// simplified together they share one heap and one rtree, so the least
+// significant vertex of any part goes first, and parts can't cross
+type VertexParts[P any] struct {
+	parts []VertexCollectionOf[P]
+	len   int
+}
+
+// vertices are renumbered part after part, so equal areas go to the
+// earlier part (then the earlier vertex)
+func NewVertexParts[P any](parts ...VertexCollectionOf[P]) *VertexParts[P] {
+	collection := &VertexParts[P]{parts: parts}
+	for _, part := range parts {
+		collection.len += part.Len()
+	}
+	index := 0
+	collection.Do(func(point *Vertex[P]) error {
+		point.Index = index
+		index++
+		return nil
+	})
+	return collection
+}
+
+func (p VertexParts[P]) Len() int {
+	return p.len
+}
+
+// Every vertex still belongs to its own part
+func (p VertexParts[P]) Prev(point *Vertex[P]) *Vertex[P] {
+	return point.Prev()
+}
+
+func (p VertexParts[P]) Next(point *Vertex[P]) *Vertex[P] {
+	return point.Next()
+}
+
+func (p VertexParts[P]) Do(f func(*Vertex[P]) error) error {
+	for _, part := range p.parts {
+		if err := part.Do(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *VertexParts[P]) Remove(e *Vertex[P]) {
+	e.list.Remove(e)
+	p.len--
+}
+
+// Veto removing points from a part with only `Min` points left
+// e.g: 3 so every loop stays a loop. Only `Parts`, if given
+type PartMinimum[P any] struct {
+	Min   int
+	Parts map[VertexCollectionOf[P]]bool
+}
+
+func (m PartMinimum[P]) Allows(point *Vertex[P]) bool {
+	if point.list == nil || (m.Parts != nil && !m.Parts[point.list]) {
+		return true
+	}
+	return point.list.Len() > m.Min
+}
@@ -2,41 +2,51 @@
 // for traversing "lines"
 package internal
 
-type PointWithTriangleList struct {
-	root PointWithTriangle // sentinal node
+import "github.com/golang/geo/s2"
+
+type VertexList[P any] struct {
+	root Vertex[P] // sentinal node
 	len  int
+	// every vertex ever pushed, for Index
+	pushed int
 }
 
-func NewPointWithTriangleList() *PointWithTriangleList {
-	list := PointWithTriangleList{}
+type PointWithTriangleList = VertexList[s2.Point]
+
+func NewVertexList[P any]() *VertexList[P] {
+	list := VertexList[P]{}
 	list.root.next = &list.root
 	list.root.prev = &list.root
 	return &list
 }
 
-func (l PointWithTriangleList) Len() int {
+func NewPointWithTriangleList() *PointWithTriangleList {
+	return NewVertexList[s2.Point]()
+}
+
+func (l VertexList[P]) Len() int {
 	return l.len
 }
 
-func (l PointWithTriangleList) front() *PointWithTriangle {
+func (l *VertexList[P]) front() *Vertex[P] {
 	return l.root.next
 }
 
-func (l *PointWithTriangleList) Prev(point *PointWithTriangle) *PointWithTriangle {
-	if point.prev == nil || *point.prev == l.root {
+func (l *VertexList[P]) Prev(point *Vertex[P]) *Vertex[P] {
+	if point.prev == nil || point.prev == &l.root {
 		return nil
 	}
 	return point.prev
 }
 
-func (l *PointWithTriangleList) Next(point *PointWithTriangle) *PointWithTriangle {
-	if point.next == nil || *point.next == l.root {
+func (l *VertexList[P]) Next(point *Vertex[P]) *Vertex[P] {
+	if point.next == nil || point.next == &l.root {
 		return nil
 	}
 	return point.next
 }
 
-func (l *PointWithTriangleList) Do(f func(*PointWithTriangle) error) error {
+func (l *VertexList[P]) Do(f func(*Vertex[P]) error) error {
 	if l.Len() == 0 {
 		return nil
 	}
@@ -48,7 +58,7 @@ func (l *PointWithTriangleList) Do(f func(*PointWithTriangle) error) error {
 	return nil
 }
 
-func (l *PointWithTriangleList) insert(e, at *PointWithTriangle) {
+func (l *VertexList[P]) insert(e, at *Vertex[P]) {
 	n := at.next
 	at.next = e
 	e.prev = at
@@ -58,11 +68,13 @@ func (l *PointWithTriangleList) insert(e, at *PointWithTriangle) {
 	l.len++
 }
 
-func (l *PointWithTriangleList) PushBack(e *PointWithTriangle) {
+func (l *VertexList[P]) PushBack(e *Vertex[P]) {
+	e.Index = l.pushed
+	l.pushed++
 	l.insert(e, l.root.prev)
 }
 
-func (l *PointWithTriangleList) Remove(e *PointWithTriangle) {
+func (l *VertexList[P]) Remove(e *Vertex[P]) {
 	e.prev.next = e.next
 	e.next.prev = e.prev
 	e.next = nil
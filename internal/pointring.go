@@ -2,39 +2,51 @@
 // for traversing "loops"
 package internal
 
-type PointWithTriangleRing struct {
-	root *PointWithTriangle
+import "github.com/golang/geo/s2"
+
+type VertexRing[P any] struct {
+	root *Vertex[P]
 	len  int
+	// every vertex ever pushed (and the root), for Index
+	pushed int
 }
 
-func NewPointWithTriangleRing(root *PointWithTriangle) *PointWithTriangleRing {
-	list := &PointWithTriangleRing{
-		root: root,
-		len:  1,
+type PointWithTriangleRing = VertexRing[s2.Point]
+
+func NewVertexRing[P any](root *Vertex[P]) *VertexRing[P] {
+	list := &VertexRing[P]{
+		root:   root,
+		len:    1,
+		pushed: 1,
 	}
+	root.Index = 0
 	root.next = root
 	root.prev = root
 	root.list = list
 	return list
 }
 
-func (r PointWithTriangleRing) Len() int {
+func NewPointWithTriangleRing(root *PointWithTriangle) *PointWithTriangleRing {
+	return NewVertexRing(root)
+}
+
+func (r VertexRing[P]) Len() int {
 	return r.len
 }
 
-func (r PointWithTriangleRing) front() *PointWithTriangle {
+func (r VertexRing[P]) front() *Vertex[P] {
 	return r.root
 }
 
-func (r PointWithTriangleRing) Prev(point *PointWithTriangle) *PointWithTriangle {
+func (r VertexRing[P]) Prev(point *Vertex[P]) *Vertex[P] {
 	return point.prev
 }
 
-func (r PointWithTriangleRing) Next(point *PointWithTriangle) *PointWithTriangle {
+func (r VertexRing[P]) Next(point *Vertex[P]) *Vertex[P] {
 	return point.next
 }
 
-func (r PointWithTriangleRing) Do(f func(*PointWithTriangle) error) error {
+func (r VertexRing[P]) Do(f func(*Vertex[P]) error) error {
 	if r.Len() == 0 {
 		return nil
 	}
@@ -49,7 +61,7 @@ func (r PointWithTriangleRing) Do(f func(*PointWithTriangle) error) error {
 	return nil
 }
 
-func (r *PointWithTriangleRing) insert(e, at *PointWithTriangle) {
+func (r *VertexRing[P]) insert(e, at *Vertex[P]) {
 	prev := at.prev
 	e.prev = prev
 	prev.next = e
@@ -60,11 +72,13 @@ func (r *PointWithTriangleRing) insert(e, at *PointWithTriangle) {
 	r.len++
 }
 
-func (r *PointWithTriangleRing) PushBack(e *PointWithTriangle) {
+func (r *VertexRing[P]) PushBack(e *Vertex[P]) {
+	e.Index = r.pushed
+	r.pushed++
 	r.insert(e, r.root)
 }
 
-func (r *PointWithTriangleRing) Remove(e *PointWithTriangle) {
+func (r *VertexRing[P]) Remove(e *Vertex[P]) {
 	e.prev.next = e.next
 	e.next.prev = e.prev
 	// special case, when removing the root, change the root to be the sibling
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/dhconnelly/rtreego"
+	"github.com/golang/geo/r3"
 	"github.com/golang/geo/s2"
 )
 
@@ -31,13 +32,31 @@ func minAndDistance(nums ...float64) (min, diff float64) {
 }
 
 func BuildRTreeRect(points ...s2.Point) (*rtreego.Rect, error) {
-	x := make([]float64, len(points))
-	y := make([]float64, len(points))
-	z := make([]float64, len(points))
+	vectors := make([]r3.Vector, len(points))
 	for i, point := range points {
-		x[i] = point.X
-		y[i] = point.Y
-		z[i] = point.Z
+		vectors[i] = point.Vector
+	}
+	return BuildRTreeRectFromVectors(vectors...)
+}
+
+// Like BuildRTreeRect, grown by `pad` on every side
+func BuildPaddedRTreeRect(pad float64, points ...s2.Point) (*rtreego.Rect, error) {
+	grow := r3.Vector{X: pad, Y: pad, Z: pad}
+	vectors := make([]r3.Vector, 0, 2*len(points))
+	for _, point := range points {
+		vectors = append(vectors, point.Sub(grow), point.Add(grow))
+	}
+	return BuildRTreeRectFromVectors(vectors...)
+}
+
+func BuildRTreeRectFromVectors(vectors ...r3.Vector) (*rtreego.Rect, error) {
+	x := make([]float64, len(vectors))
+	y := make([]float64, len(vectors))
+	z := make([]float64, len(vectors))
+	for i, vector := range vectors {
+		x[i] = vector.X
+		y[i] = vector.Y
+		z[i] = vector.Z
 	}
 
 	minX, xDistance := minAndDistance(x...)
@@ -85,4 +104,10 @@ func newBoundableEdge(edge s2.Edge) (boundableEdge, error) {
 		Edge: edge,
 		rect: rect,
 	}, nil
-}
\ No newline at end of file
+}
+
+// A single point has no extent, give it some fudge so rTree accepts it
+func BuildRTreePoint(point s2.Point) (*rtreego.Rect, error) {
+	min := rtreego.Point{point.X, point.Y, point.Z}
+	return rtreego.NewRect(min, []float64{0.0001, 0.0001, 0.0001})
+}
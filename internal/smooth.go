@@ -0,0 +1,161 @@
+// Smoothing, replacing sections of a line with curves
+// sections either cut a corner, from the middle of the edge before a
+// vertex to the middle of the edge after, or follow one edge
+package internal
+
+import (
+	"fmt"
+
+	"github.com/dhconnelly/rtreego"
+	"github.com/golang/geo/s2"
+)
+
+// A run of the line and the curve that may replace it
+type Section struct {
+	Original, Smoothed []s2.Point
+	// the indices of the line's edges the section (partly) covers
+	Edges []int
+}
+
+// Chaikin's corner cutting, `iterations` times, on the corner from
+// the middle of the edge before `corner` to the middle of the edge after
+// the same as cutting the whole line, section by section
+func ChaikinCorner(before, corner, after s2.Point, iterations int) []s2.Point {
+	section := []s2.Point{before, corner, after}
+	for i := 0; i < iterations; i++ {
+		last := len(section) - 1
+		// the first and last edges are half of an edge of the line
+		// their quarter points are the midpoints of the halves
+		cut := []s2.Point{section[0], s2.Interpolate(0.5, section[0], section[1])}
+		for j := 1; j < last-1; j++ {
+			cut = append(cut,
+				s2.Interpolate(0.25, section[j], section[j+1]),
+				s2.Interpolate(0.75, section[j], section[j+1]),
+			)
+		}
+		cut = append(cut, s2.Interpolate(0.5, section[last-1], section[last]), section[last])
+		section = cut
+	}
+	return section
+}
+
+// The quadratic Bézier curve from `before` to `after`, pulled towards
+// `corner`, at `samples` edges. Chaikin's limit
+func BezierCorner(before, corner, after s2.Point, samples int) []s2.Point {
+	section := make([]s2.Point, samples+1)
+	for i := range section {
+		t := float64(i) / float64(samples)
+		section[i] = s2.Point{Vector: before.Mul((1 - t) * (1 - t)).
+			Add(corner.Mul(2 * t * (1 - t))).
+			Add(after.Mul(t * t)).
+			Normalize()}
+	}
+	section[0], section[samples] = before, after
+	return section
+}
+
+// The uniform Catmull-Rom spline from b to c at `samples` edges,
+// a and d are the vertices either side. It passes through every vertex
+func CatmullRomEdge(a, b, c, d s2.Point, samples int) []s2.Point {
+	section := make([]s2.Point, samples+1)
+	for i := range section {
+		t := float64(i) / float64(samples)
+		t2, t3 := t*t, t*t*t
+		v := b.Mul(2).
+			Add(c.Sub(a.Vector).Mul(t)).
+			Add(a.Mul(2).Sub(b.Mul(5)).Add(c.Mul(4)).Sub(d.Vector).Mul(t2)).
+			Add(b.Mul(3).Sub(a.Vector).Sub(c.Mul(3)).Add(d.Vector).Mul(t3))
+		section[i] = s2.Point{Vector: v.Normalize()}
+	}
+	section[0], section[samples] = b, c
+	return section
+}
+
+// An edge of the line, or of a curve already chosen (edge -1)
+type indexedEdge struct {
+	s2.Edge
+	edge int
+	rect *rtreego.Rect
+}
+
+func (e indexedEdge) Bounds() *rtreego.Rect {
+	return e.rect
+}
+
+// Choose, section by section, the smoothed curve or the original
+//
+// with avoidIntersections a curve is only used if it doesn't cross the line
+// (bar the edges the section covers) or any curve chosen before it. So
+// smoothing never creates an intersection. Returns the sections chosen and
+// how many curves were rejected
+func ChooseSections(
+	edges []s2.Edge,
+	sections []Section,
+	avoidIntersections bool,
+) (chosen [][]s2.Point, rejected int, err error) {
+	chosen = make([][]s2.Point, len(sections))
+	if !avoidIntersections {
+		for i, section := range sections {
+			chosen[i] = section.Smoothed
+		}
+		return chosen, 0, nil
+	}
+
+	rtree := rtreego.NewTree(3, 25, 50)
+	insert := func(edge s2.Edge, id int) error {
+		if edge.V0 == edge.V1 {
+			return nil
+		}
+		rect, err := BuildRTreeRectFromVectors(edge.V0.Vector, edge.V1.Vector)
+		if err != nil {
+			return err
+		}
+		rtree.Insert(indexedEdge{Edge: edge, edge: id, rect: rect})
+		return nil
+	}
+	for i, edge := range edges {
+		if err := insert(edge, i); err != nil {
+			return nil, 0, fmt.Errorf("edge `%d`: %w", i, err)
+		}
+	}
+
+	for i, section := range sections {
+		covered := map[int]bool{}
+		for _, edge := range section.Edges {
+			covered[edge] = true
+		}
+		if curveCrosses(rtree, section.Smoothed, covered) {
+			chosen[i] = section.Original
+			rejected++
+			continue
+		}
+		chosen[i] = section.Smoothed
+		for j := 1; j < len(section.Smoothed); j++ {
+			if err := insert(s2.Edge{V0: section.Smoothed[j-1], V1: section.Smoothed[j]}, -1); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+	return chosen, rejected, nil
+}
+
+func curveCrosses(rtree *rtreego.Rtree, curve []s2.Point, covered map[int]bool) bool {
+	for j := 1; j < len(curve); j++ {
+		edge := s2.Edge{V0: curve[j-1], V1: curve[j]}
+		rect, err := BuildRTreeRectFromVectors(edge.V0.Vector, edge.V1.Vector)
+		if err != nil {
+			// a curve edge of no length crosses nothing
+			continue
+		}
+		for _, candidateI := range rtree.SearchIntersect(rect) {
+			candidate := candidateI.(indexedEdge)
+			if candidate.edge >= 0 && covered[candidate.edge] {
+				continue
+			}
+			if EdgesCross(edge, candidate.Edge) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+// Snap rounding, rounding vertices to a grid without creating crossings
+// after Hobby, "Practical segment intersection with finite precision output"
+package internal
+
+import (
+	"math"
+	"sort"
+
+	"github.com/golang/geo/r2"
+)
+
+// The center of the grid cell (pixel) containing p, the grid has unit spacing
+func hotPixel(p r2.Point) r2.Point {
+	return r2.Point{X: math.Floor(p.X + 0.5), Y: math.Floor(p.Y + 0.5)}
+}
+
+// Round `lines` to the unit grid
+//
+// every pixel with a vertex or a crossing in it is "hot", and any edge passing
+// through a hot pixel is bent through its center. Edges may end up touching
+// or sharing a vertex, but none cross that didn't before
+// lines are snapped together, so they can't cross each other either
+func SnapRound(lines [][]r2.Point) [][]r2.Point {
+	hot := map[r2.Point]bool{}
+	edges := []Edge[r2.Point]{}
+	for _, line := range lines {
+		for i, p := range line {
+			hot[hotPixel(p)] = true
+			if i > 0 {
+				edges = append(edges, Edge[r2.Point]{V0: line[i-1], V1: p})
+			}
+		}
+	}
+	for _, p := range crossings(edges) {
+		hot[hotPixel(p)] = true
+	}
+
+	pixels := make([]r2.Point, 0, len(hot))
+	for pixel := range hot {
+		pixels = append(pixels, pixel)
+	}
+	sort.Slice(pixels, func(i, j int) bool {
+		return pixels[i].X < pixels[j].X || (pixels[i].X == pixels[j].X && pixels[i].Y < pixels[j].Y)
+	})
+
+	output := make([][]r2.Point, len(lines))
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		snapped := []r2.Point{hotPixel(line[0])}
+		for j := 1; j < len(line); j++ {
+			for _, pixel := range hotPixelsAlong(pixels, line[j-1], line[j]) {
+				if snapped[len(snapped)-1] != pixel {
+					snapped = append(snapped, pixel)
+				}
+			}
+		}
+		output[i] = snapped
+	}
+	return output
+}
+
+// The hot pixels the segment ab passes through, in order from a to b
+// `pixels` are sorted by X
+func hotPixelsAlong(pixels []r2.Point, a, b r2.Point) []r2.Point {
+	first, last := hotPixel(a), hotPixel(b)
+	type entry struct {
+		t     float64
+		pixel r2.Point
+	}
+	entries := []entry{}
+
+	lo, hi := math.Min(a.X, b.X)-0.5, math.Max(a.X, b.X)+0.5
+	minY, maxY := math.Min(a.Y, b.Y)-0.5, math.Max(a.Y, b.Y)+0.5
+	for i := sort.Search(len(pixels), func(i int) bool { return pixels[i].X >= lo }); i < len(pixels) && pixels[i].X <= hi; i++ {
+		pixel := pixels[i]
+		if pixel.Y < minY || pixel.Y > maxY || pixel == first || pixel == last {
+			continue
+		}
+		if t, ok := segmentEntersSquare(a, b, pixel); ok {
+			entries = append(entries, entry{t, pixel})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].t < entries[j].t
+	})
+
+	along := make([]r2.Point, 0, len(entries)+2)
+	along = append(along, first)
+	for _, e := range entries {
+		along = append(along, e.pixel)
+	}
+	return append(along, last)
+}
+
+// Liang-Barsky against the pixel centered on `center`
+// where along ab the segment first touches it
+func segmentEntersSquare(a, b, center r2.Point) (float64, bool) {
+	d := b.Sub(a)
+	t0, t1 := 0.0, 1.0
+	for _, edge := range [][2]float64{
+		{-d.X, a.X - (center.X - 0.5)},
+		{d.X, (center.X + 0.5) - a.X},
+		{-d.Y, a.Y - (center.Y - 0.5)},
+		{d.Y, (center.Y + 0.5) - a.Y},
+	} {
+		p, q := edge[0], edge[1]
+		if p == 0 {
+			if q < 0 {
+				return 0, false
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 && t > t0 {
+			t0 = t
+		} else if p > 0 && t < t1 {
+			t1 = t
+		}
+		if t0 > t1 {
+			return 0, false
+		}
+	}
+	return t0, true
+}
+
+// Where any of `edges` cross one another
+// a sweep along X, only edges overlapping in X are compared
+func crossings(edges []Edge[r2.Point]) []r2.Point {
+	sorted := append([]Edge[r2.Point]{}, edges...)
+	minX := func(e Edge[r2.Point]) float64 { return math.Min(e.V0.X, e.V1.X) }
+	maxX := func(e Edge[r2.Point]) float64 { return math.Max(e.V0.X, e.V1.X) }
+	sort.Slice(sorted, func(i, j int) bool {
+		return minX(sorted[i]) < minX(sorted[j])
+	})
+
+	points := []r2.Point{}
+	for i, a := range sorted {
+		for j := i + 1; j < len(sorted) && minX(sorted[j]) <= maxX(a); j++ {
+			b := sorted[j]
+			if !planarEdgesCross(a.V0, a.V1, b.V0, b.V1) {
+				continue
+			}
+			da, db := a.V1.Sub(a.V0), b.V1.Sub(b.V0)
+			t := b.V0.Sub(a.V0).Cross(db) / da.Cross(db)
+			points = append(points, a.V0.Add(da.Mul(t)))
+		}
+	}
+	return points
+}
@@ -0,0 +1,204 @@
+// Streaming simplification, the classic front to back algorithms
+// from each kept vertex (the key) they look ahead for the next one to keep
+package internal
+
+import (
+	"math"
+
+	"github.com/dhconnelly/rtreego"
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// Propose the next vertex to keep after `key`, every vertex between is removed
+// never beyond `end`, the last vertex of a line or the first of a ring
+type NextKey func(key, end *PointWithTriangle) *PointWithTriangle
+
+// Walk the line front to back, removing the vertices between each key and
+// the next key proposed by `next`
+//
+// vertices are removed one at a time, in order, with the same intersection
+// checks and constraints as Visvalingam. Removing them in order sweeps the
+// new edge across the vertices it replaces, so every triangle swept is
+// checked. If a removal is rejected the vertex becomes the next key instead
+// rings start and end at their first vertex, which is kept
+func Streaming(
+	pointList VertexCollection,
+	next NextKey,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	constraints ...Constraint,
+) (stats Stats, err error) {
+	r, err := newRemover(pointList, avoidIntersections, constraints)
+	if err != nil {
+		return stats, err
+	}
+	var first, last *PointWithTriangle
+	pointList.Do(func(point *PointWithTriangle) error {
+		if first == nil {
+			first = point
+		}
+		last = point
+		return nil
+	})
+	if first == nil {
+		return r.stats, nil
+	}
+
+	end := last
+	if first.Prev() != nil {
+		end = first
+	}
+	for key := first; key.Next() != nil && key.Next() != key; {
+		target := next(key, end)
+		for point := key.Next(); point != target; point = key.Next() {
+			if pointList.Len() <= minPointsToKeep {
+				return r.stats, nil
+			}
+			ok, err := r.remove(point)
+			if err != nil {
+				return r.stats, err
+			}
+			if !ok {
+				break
+			}
+		}
+		if key = key.Next(); key == end {
+			break
+		}
+	}
+	return r.stats, nil
+}
+
+// Removes vertices one at a time, with the same intersection checks and
+// constraints as Visvalingam
+type remover struct {
+	pointList          VertexCollection
+	rtree              *rtreego.Rtree
+	avoidIntersections bool
+	constraints        []Constraint
+	stats              Stats
+}
+
+func newRemover(pointList VertexCollection, avoidIntersections bool, constraints []Constraint) (*remover, error) {
+	r := &remover{
+		pointList:          pointList,
+		rtree:              rtreego.NewTree(3, 25, 50),
+		avoidIntersections: avoidIntersections,
+		constraints:        constraints,
+	}
+	// constraints look at the triangles' boxes, intersecting or not
+	err := pointList.Do(func(point *PointWithTriangle) (err error) {
+		if point.BBox, err = TriangleBbox(point); err != nil {
+			return err
+		}
+		if avoidIntersections {
+			r.rtree.Insert(point)
+		}
+		return nil
+	})
+	return r, err
+}
+
+// Remove `point` unless it would create an intersection or a constraint
+// vetoes it, reports whether it was removed
+func (r *remover) remove(point *PointWithTriangle) (bool, error) {
+	if r.avoidIntersections && createsIntersection[s2.Point](Spherical{}, r.rtree, point) != nil {
+		r.stats.Intersections++
+		return false, nil
+	}
+	if !allowed(r.constraints, point) {
+		r.stats.Vetoed++
+		return false, nil
+	}
+
+	prev, after := point.Prev(), point.Next()
+	if r.avoidIntersections {
+		r.rtree.Delete(point)
+		r.rtree.Delete(prev)
+		r.rtree.Delete(after)
+	}
+	removed(r.constraints, point)
+	r.pointList.Remove(point)
+	for _, neighbour := range []*PointWithTriangle{prev, after} {
+		var err error
+		if neighbour.BBox, err = TriangleBbox(neighbour); err != nil {
+			return true, err
+		}
+		if r.avoidIntersections {
+			r.rtree.Insert(neighbour)
+		}
+	}
+	return true, nil
+}
+
+// The first vertex after `key` at least `tolerance` from it
+func RadialDistance(tolerance s1.Angle) NextKey {
+	return func(key, end *PointWithTriangle) *PointWithTriangle {
+		point := key.Next()
+		for point != end && key.Point.Distance(point.Point) < tolerance {
+			point = point.Next()
+		}
+		return point
+	}
+}
+
+// Keep going while vertices stay within `tolerance` of the great circle
+// through the key and the vertex after it
+func ReumannWitkam(tolerance s1.Angle) NextKey {
+	return func(key, end *PointWithTriangle) *PointWithTriangle {
+		return alongStrip(key, key.Next(), end, tolerance, s1.InfAngle())
+	}
+}
+
+// Like ReumannWitkam, the strip is set by the first vertex at least
+// `tolerance` from the key and ends `maxTolerance` from the key
+func Opheim(tolerance, maxTolerance s1.Angle) NextKey {
+	return func(key, end *PointWithTriangle) *PointWithTriangle {
+		return alongStrip(key, RadialDistance(tolerance)(key, end), end, tolerance, maxTolerance)
+	}
+}
+
+// The last vertex from `direction` on within `tolerance` of the great
+// circle through key and direction, and within `maxDistance` of key
+func alongStrip(key, direction, end *PointWithTriangle, tolerance, maxDistance s1.Angle) *PointWithTriangle {
+	point := direction
+	for ; point != end; point = point.Next() {
+		candidate := point.Next().Point
+		if lineDistance(key.Point, direction.Point, candidate) > tolerance ||
+			key.Point.Distance(candidate) > maxDistance {
+			break
+		}
+	}
+	return point
+}
+
+// How far `p` is from the great circle through a and b
+func lineDistance(a, b, p s2.Point) s1.Angle {
+	if a == b {
+		return a.Distance(p)
+	}
+	normal := a.PointCross(b)
+	return s1.Angle(math.Abs(math.Pi/2 - normal.Angle(p.Vector).Radians()))
+}
+
+// Look `lookAhead` vertices ahead, then back off until every vertex
+// between is within `tolerance` of the edge from the key
+func Lang(tolerance s1.Angle, lookAhead int) NextKey {
+	return func(key, end *PointWithTriangle) *PointWithTriangle {
+		ahead := key.Next()
+		for i := 1; i < lookAhead && ahead != end; i++ {
+			ahead = ahead.Next()
+		}
+		for ; ahead != key.Next(); ahead = ahead.Prev() {
+			within := true
+			for point := key.Next(); point != ahead && within; point = point.Next() {
+				within = s2.DistanceFromSegment(point.Point, key.Point, ahead.Point) <= tolerance
+			}
+			if within {
+				break
+			}
+		}
+		return ahead
+	}
+}
@@ -5,58 +5,71 @@ import (
 	"math"
 
 	"github.com/dhconnelly/rtreego"
+	"github.com/golang/geo/r3"
 	"github.com/golang/geo/s2"
 )
 
 // Interface for traversing our shape that we
 // can implement in ring & non-ring ways
 // please see pointlist.go and pointring.go for concrete implementations
-type VertexCollection interface {
-	Remove(*PointWithTriangle)
+type VertexCollectionOf[P any] interface {
+	Remove(*Vertex[P])
 	Len() int
-	Do(f func(*PointWithTriangle) error) error
-	Prev(p *PointWithTriangle) *PointWithTriangle
-	Next(p *PointWithTriangle) *PointWithTriangle
+	Do(f func(*Vertex[P]) error) error
+	Prev(p *Vertex[P]) *Vertex[P]
+	Next(p *Vertex[P]) *Vertex[P]
 }
 
-type PointWithTriangle struct {
-	Point s2.Point
+type VertexCollection = VertexCollectionOf[s2.Point]
+
+// A point of type P in the shape being simplified
+type Vertex[P any] struct {
+	Point P
 	// The previous and next points in the shape
 	// for a loop this can be cyclical
-	prev, next *PointWithTriangle
+	prev, next *Vertex[P]
 	// The area this triangle occupies with
 	// the triangle (point-1)(point)(point+1)
 	Area      float64
 	HeapIndex int
+	// Where the vertex was added to its collection, 0 first
+	// equal areas are broken by it, see VertexHeap.Less
+	Index int
 	// the bounding box of the triangle formed
 	BBox *rtreego.Rect
-	list VertexCollection
+	list VertexCollectionOf[P]
 }
 
-func NewPointWithTriangle(point s2.Point) *PointWithTriangle {
-	t := PointWithTriangle{
+type PointWithTriangle = Vertex[s2.Point]
+
+func NewVertex[P any](point P) *Vertex[P] {
+	t := Vertex[P]{
 		Point:     point,
 		HeapIndex: -1,
 	}
 	return &t
 }
 
+func NewPointWithTriangle(point s2.Point) *PointWithTriangle {
+	return NewVertex(point)
+}
+
 // Defer to the list for next behaviour
-func (p *PointWithTriangle) Next() *PointWithTriangle {
+func (p *Vertex[P]) Next() *Vertex[P] {
 	if p.list == nil {
 		return nil
 	}
 	return p.list.Next(p)
 }
 
-func (p *PointWithTriangle) Prev() *PointWithTriangle {
+func (p *Vertex[P]) Prev() *Vertex[P] {
 	if p.list == nil {
 		return nil
 	}
 	return p.list.Prev(p)
 }
 
-func (p PointWithTriangle) Bounds() *rtreego.Rect {
+func (p *Vertex[P]) Bounds() *rtreego.Rect {
 	return p.BBox
 }
 
@@ -68,6 +81,21 @@ func CreatesIntersection(
 	rtree *rtreego.Rtree,
 	point *PointWithTriangle,
 ) []s2.Edge {
+	edges := createsIntersection[s2.Point](Spherical{}, rtree, point)
+	if edges == nil {
+		return nil
+	}
+	return []s2.Edge{
+		{V0: edges[0].V0, V1: edges[0].V1},
+		{V0: edges[1].V0, V1: edges[1].V1},
+	}
+}
+
+func createsIntersection[P any](
+	geometry Geometry[P],
+	rtree *rtreego.Rtree,
+	point *Vertex[P],
+) []Edge[P] {
 	// special case, this is the start or end of a polyline
 	// it's always preserved
 	if point.Prev() == nil || point.Next() == nil {
@@ -75,12 +103,12 @@ func CreatesIntersection(
 	}
 	candidates := rtree.SearchIntersect(point.Bounds())
 	// by remove the point `b` in `abc` we'd create a new edge `ac`
-	proposedEdge := s2.Edge{point.Prev().Point, point.Next().Point}
+	proposedEdge := Edge[P]{point.Prev().Point, point.Next().Point}
 
 	// for efficiency we only look at other edges that were in the points
 	// bounding box. any outside are guaranteed not to intersect
 	for _, candidateI := range candidates {
-		candidate := candidateI.(*PointWithTriangle)
+		candidate := candidateI.(*Vertex[P])
 		// might hit ourselves
 		if candidate == point {
 			continue
@@ -88,17 +116,17 @@ func CreatesIntersection(
 
 		// Check if the ab edge would intersect with our proposed edge
 		if prev := candidate.Prev(); prev != nil {
-			ab := s2.Edge{candidate.Point, prev.Point}
-			if EdgesCross(proposedEdge, ab) {
-				return []s2.Edge{proposedEdge, ab}
+			ab := Edge[P]{candidate.Point, prev.Point}
+			if geometry.EdgesCross(proposedEdge.V0, proposedEdge.V1, ab.V0, ab.V1) {
+				return []Edge[P]{proposedEdge, ab}
 			}
 		}
 
 		// Check if the ac edge would intersect with our proposed edge
 		if next := candidate.Next(); next != nil {
-			bc := s2.Edge{candidate.Point, next.Point}
-			if EdgesCross(proposedEdge, bc) {
-				return []s2.Edge{proposedEdge, bc}
+			bc := Edge[P]{candidate.Point, next.Point}
+			if geometry.EdgesCross(proposedEdge.V0, proposedEdge.V1, bc.V0, bc.V1) {
+				return []Edge[P]{proposedEdge, bc}
 			}
 		}
 	}
@@ -107,26 +135,67 @@ func CreatesIntersection(
 }
 
 func TriangleArea(point *PointWithTriangle) float64 {
+	return triangleArea[s2.Point](Spherical{}, point)
+}
+
+func triangleArea[P any](geometry Geometry[P], point *Vertex[P]) float64 {
 	if point.Prev() == nil || point.Next() == nil {
 		return math.Inf(1)
 	}
 
-	// Note: under the covers this will defer to GirardArea if possible
-	// no need to swap it out
-	return s2.PointArea(point.Prev().Point, point.Point, point.Next().Point)
+	if windowed, ok := geometry.(Windowed[P]); ok {
+		window, at := liveWindow(point, windowed.Reach())
+		return windowed.RankWindow(window, at)
+	}
+	return geometry.TriangleArea(point.Prev().Point, point.Point, point.Next().Point)
+}
+
+// The live vertices up to `reach` either side of point, point is window[at]
+// shorter near the ends of a line, and a loop's vertices aren't repeated
+func liveWindow[P any](point *Vertex[P], reach int) (window []P, at int) {
+	others := point.list.Len() - 1
+	before := []*Vertex[P]{}
+	for v := point.Prev(); v != nil && len(before) < reach && len(before) < others; v = v.Prev() {
+		before = append(before, v)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		window = append(window, before[i].Point)
+	}
+	at = len(window)
+	window = append(window, point.Point)
+	after := 0
+	for v := point.Next(); v != nil && after < reach && len(before)+after < others; v = v.Next() {
+		window = append(window, v.Point)
+		after++
+	}
+	return window, at
 }
 
 func TriangleBbox(point *PointWithTriangle) (*rtreego.Rect, error) {
-	points := []s2.Point{point.Point}
+	return triangleBbox[s2.Point](Spherical{}, point)
+}
+
+func triangleBbox[P any](geometry Geometry[P], point *Vertex[P]) (*rtreego.Rect, error) {
+	coordinates := []r3.Vector{geometry.Coordinates(point.Point)}
 
 	if prevPoint := point.Prev(); prevPoint != nil {
-		points = append(points, prevPoint.Point)
+		coordinates = append(coordinates, geometry.Coordinates(prevPoint.Point))
 	}
 	if nextPoint := point.Next(); nextPoint != nil {
-		points = append(points, nextPoint.Point)
+		coordinates = append(coordinates, geometry.Coordinates(nextPoint.Point))
 	}
 
-	return BuildRTreeRect(points...)
+	return BuildRTreeRectFromVectors(coordinates...)
+}
+
+// What happened while simplifying, for tuning thresholds
+type Stats struct {
+	// removals put off because they'd create an intersection
+	Intersections int
+	// removals put off because a constraint vetoed them
+	Vetoed int
+	// the effective area of the last point removed
+	EffectiveArea float64
 }
 
 func Visvalingam(
@@ -134,9 +203,30 @@ func Visvalingam(
 	threshold float64,
 	minPointsToKeep int,
 	avoidIntersections bool,
-) (err error) {
+	constraints ...Constraint,
+) (stats Stats, err error) {
+	return VisvalingamWithGeometry[s2.Point](
+		Spherical{},
+		pointList,
+		threshold,
+		minPointsToKeep,
+		avoidIntersections,
+		constraints...,
+	)
+}
+
+// Visvalingam over any point type, the area, bounding box and
+// intersection maths are provided by `geometry`
+func VisvalingamWithGeometry[P any](
+	geometry Geometry[P],
+	pointList VertexCollectionOf[P],
+	threshold float64,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	constraints ...ConstraintOf[P],
+) (stats Stats, err error) {
 
-	minHeap := &PointWithTriangleHeap{}
+	minHeap := &VertexHeap[P]{}
 	heap.Init(minHeap)
 
 	// the r-tree self balances, but constrain the # branches
@@ -147,10 +237,10 @@ func Visvalingam(
 	// Build a rtree
 	rtree := rtreego.NewTree(3, minBranchFactor, maxBranchFactor)
 
-	if err = pointList.Do(func(point *PointWithTriangle) error {
+	if err = pointList.Do(func(point *Vertex[P]) error {
 		// set the area and bounding box
-		point.Area = TriangleArea(point)
-		point.BBox, err = TriangleBbox(point)
+		point.Area = triangleArea(geometry, point)
+		point.BBox, err = triangleBbox(geometry, point)
 		if err != nil {
 			return err
 		}
@@ -160,16 +250,16 @@ func Visvalingam(
 		rtree.Insert(point)
 		return nil
 	}); err != nil {
-		return err
+		return stats, err
 	}
 
 	maxArea := 0.0
-	intersecting := []*PointWithTriangle{}
+	intersecting := []*Vertex[P]{}
 	// Pop the heap, because the heap maintains order by area
 	// this means the point that forms the smallest area
 	// will be removed front (tl;dr: most useless point removed first)
 	for elementI := heap.Pop(minHeap); elementI != nil; elementI = heap.Pop(minHeap) {
-		head := elementI.(*PointWithTriangle)
+		head := elementI.(*Vertex[P])
 
 		// If the area of the current point is less than that of the previous point
 		// to be eliminated, use the latters area instead. This ensures that the
@@ -185,15 +275,23 @@ func Visvalingam(
 
 		// if removing the node b in the triangle abc
 		// would cause an intersection do not actually remove it
-		if avoidIntersections && CreatesIntersection(rtree, head) != nil {
+		if avoidIntersections && createsIntersection(geometry, rtree, head) != nil {
 			intersecting = append(intersecting, head)
+			stats.Intersections++
+			continue
+		}
+
+		// likewise if any of our constraints would be broken
+		if !allowed(constraints, head) {
+			intersecting = append(intersecting, head)
+			stats.Vetoed++
 			continue
 		}
 
 		for _, intersectingElement := range intersecting {
 			heap.Push(minHeap, intersectingElement)
 		}
-		intersecting = []*PointWithTriangle{}
+		intersecting = []*Vertex[P]{}
 
 		// If this area is greater than the threshold time to stop
 		// removing points
@@ -214,6 +312,8 @@ func Visvalingam(
 		}
 
 		// Remove our entry from the linked list
+		stats.EffectiveArea = head.Area
+		removed(constraints, head)
 		pointList.Remove(head)
 
 		// If we've reached the minimum number of points stop
@@ -226,15 +326,15 @@ func Visvalingam(
 		// the heap will need to be rebuilt too
 		if prev != nil {
 			// Keep the heap up to date
-			prev.Area = TriangleArea(prev)
+			prev.Area = triangleArea(geometry, prev)
 			if prev.HeapIndex > -1 {
 				heap.Fix(minHeap, prev.HeapIndex)
 			}
 
 			// keep the rtree up to date
-			prev.BBox, err = TriangleBbox(prev)
+			prev.BBox, err = triangleBbox(geometry, prev)
 			if err != nil {
-				return err
+				return stats, err
 			}
 			rtree.Insert(prev)
 		}
@@ -243,19 +343,40 @@ func Visvalingam(
 		// the heap will need to be rebuilt too
 		if next != nil {
 			// Keep the heap up to date
-			next.Area = TriangleArea(next)
+			next.Area = triangleArea(geometry, next)
 			if next.HeapIndex > -1 {
 				heap.Fix(minHeap, next.HeapIndex)
 			}
 
 			// keep the rtree up to date
-			next.BBox, err = TriangleBbox(next)
+			next.BBox, err = triangleBbox(geometry, next)
 			if err != nil {
-				return err
+				return stats, err
 			}
 			rtree.Insert(next)
 		}
+
+		// a windowed rank depends on vertices further out than prev and next
+		// their bounding boxes are unchanged, only the ranks need updating
+		if windowed, ok := geometry.(Windowed[P]); ok {
+			rerank := func(point *Vertex[P]) {
+				point.Area = triangleArea(geometry, point)
+				if point.HeapIndex > -1 {
+					heap.Fix(minHeap, point.HeapIndex)
+				}
+			}
+			for i, point := 1, prev; point != nil && i < windowed.Reach(); i++ {
+				if point = point.Prev(); point != nil {
+					rerank(point)
+				}
+			}
+			for i, point := 1, next; point != nil && i < windowed.Reach(); i++ {
+				if point = point.Next(); point != nil {
+					rerank(point)
+				}
+			}
+		}
 	}
 
-	return nil
+	return stats, nil
 }
@@ -1,6 +1,7 @@
 package internal_test
 
 import (
+	"container/heap"
 	"math"
 
 	"github.com/dhconnelly/rtreego"
@@ -115,4 +116,23 @@ var _ = Describe("Visvalingam unit tests", func() {
 
 	})
 
+	Describe("breaking ties in the heap", func() {
+		It("should pop equal areas in the order they were added", func() {
+			pointList := internal.NewPointWithTriangleList()
+			vertices := make([]*internal.PointWithTriangle, 8)
+			for i := range vertices {
+				vertices[i] = internal.NewPointWithTriangle(s2.PointFromLatLng(s2.LatLngFromDegrees(0, float64(i))))
+				vertices[i].Area = 1
+				pointList.PushBack(vertices[i])
+			}
+			// pushed out of order
+			minHeap := &internal.PointWithTriangleHeap{}
+			for _, i := range []int{5, 2, 7, 0, 3, 6, 1, 4} {
+				heap.Push(minHeap, vertices[i])
+			}
+			for i := range vertices {
+				Ω(heap.Pop(minHeap)).Should(Equal(vertices[i]))
+			}
+		})
+	})
 })
@@ -0,0 +1,122 @@
+package geosimplification
+
+import (
+	"math"
+
+	"github.com/golang/geo/s2"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+// How important a vertex is, the least important is removed first
+// and simplification stops once every vertex left is at least the threshold
+//
+// prev and next are the vertex's neighbours now, not in the original, since
+// removing a vertex changes the neighbourhood of the vertices either side.
+// The ends of a line are never ranked, they're always kept.
+// To see further along the line use a WindowedMetric
+type VertexMetric interface {
+	Importance(prev, current, next s2.Point) float64
+}
+
+// Rank vertices with `metric` rather than by the area of their triangle
+// thresholds are in the metric's units, AtZoom assumes an area (steradians)
+func WithMetric(metric VertexMetric) Option {
+	return func(o *options) {
+		o.metric = metric
+	}
+}
+
+// Like VertexMetric, for metrics that look further than a vertex's
+// neighbours, e.g: the curvature over several vertices
+//
+// window holds the vertices still left up to Reach either side of current,
+// current is window[at]. It's shorter near the ends of a line and never
+// repeats a vertex of a loop. Removing a vertex re-ranks every vertex
+// whose window it was in
+type WindowedMetric interface {
+	Reach() int
+	ImportanceIn(window []s2.Point, at int) float64
+}
+
+// Rank vertices with `metric` over a window of their live neighbours
+// as WithMetric, the last of the two given wins
+func WithWindowedMetric(metric WindowedMetric) Option {
+	return func(o *options) {
+		o.metric = windowedMetric{metric}
+	}
+}
+
+// a WindowedMetric where a VertexMetric is expected, so the checks
+// for a metric (e.g: streaming algorithms refusing one) still apply
+type windowedMetric struct {
+	WindowedMetric
+}
+
+func (w windowedMetric) Importance(prev, current, next s2.Point) float64 {
+	return w.ImportanceIn([]s2.Point{prev, current, next}, 1)
+}
+
+// The geometry Visvalingam ranks vertices with
+func (o *options) geometry() internal.Geometry[s2.Point] {
+	if o.metric == nil {
+		return internal.Spherical{}
+	}
+	if windowed, ok := o.metric.(windowedMetric); ok {
+		return internal.WindowRanked[s2.Point]{Geometry: internal.Spherical{}, Window: windowed.Reach(), Rank: windowed.ImportanceIn}
+	}
+	return internal.Ranked[s2.Point]{Geometry: internal.Spherical{}, Rank: o.metric.Importance}
+}
+
+// The area of the triangle prev, current, next in steradians
+// the default, Visvalingam's effective area
+type TriangleArea struct{}
+
+func (TriangleArea) Importance(prev, current, next s2.Point) float64 {
+	return s2.PointArea(prev, current, next)
+}
+
+// The triangle's area scaled by 1 - K cos θ, θ the angle at current
+// as mapshaper's weighted Visvalingam, which uses K = 0.7
+// spikes (θ near 0) shrink and go sooner, gentle bends (θ near π) stay longer
+type WeightedTriangleArea struct {
+	K float64
+}
+
+func (w WeightedTriangleArea) Importance(prev, current, next s2.Point) float64 {
+	area := s2.PointArea(prev, current, next)
+	if prev == current || next == current {
+		return area
+	}
+	return area * (1 - w.K*math.Cos(interiorAngle(prev, current, next)))
+}
+
+// How far (radians) current is from the edge prev, next
+// as Douglas-Peucker measures
+type PerpendicularDistance struct{}
+
+func (PerpendicularDistance) Importance(prev, current, next s2.Point) float64 {
+	return s2.DistanceFromSegment(current, prev, next).Radians()
+}
+
+// How far (radians) the line turns at current, straight on is 0
+type TurningAngle struct{}
+
+func (TurningAngle) Importance(prev, current, next s2.Point) float64 {
+	if prev == current || next == current {
+		return 0
+	}
+	return math.Pi - interiorAngle(prev, current, next)
+}
+
+// The length (radians) of the shorter edge either side of current
+// short edges, the densest detail, go first
+type SegmentLength struct{}
+
+func (SegmentLength) Importance(prev, current, next s2.Point) float64 {
+	return math.Min(prev.Distance(current).Radians(), current.Distance(next).Radians())
+}
+
+// The angle prev, current, next, from 0 for a spike to π for straight on
+func interiorAngle(prev, current, next s2.Point) float64 {
+	return s2.Angle(prev, current, next).Radians()
+}
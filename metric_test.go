@@ -0,0 +1,122 @@
+package geosimplification_test
+
+import (
+	"math"
+
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+// Ranks vertices by latitude, the southernmost go first
+type southernmost struct{}
+
+func (southernmost) Importance(prev, current, next s2.Point) float64 {
+	return s2.LatLngFromPoint(current).Lat.Radians() + math.Pi
+}
+
+// Ranks vertices by latitude, as southernmost, remembering the windows it saw
+type windowRecorder struct {
+	reach   int
+	windows [][]s2.Point
+}
+
+func (w *windowRecorder) Reach() int {
+	return w.reach
+}
+
+func (w *windowRecorder) ImportanceIn(window []s2.Point, at int) float64 {
+	w.windows = append(w.windows, append([]s2.Point{}, window...))
+	return s2.LatLngFromPoint(window[at]).Lat.Radians() + math.Pi
+}
+
+var _ = Describe("Vertex metric unit tests", func() {
+	point := func(lat, lng float64) s2.Point {
+		return s2.PointFromLatLng(s2.LatLngFromDegrees(lat, lng))
+	}
+	// a right angle with one degree arms
+	prev, current, next := point(0, 0), point(1, 0), point(1, 1)
+
+	It("should measure the built in metrics", func() {
+		Ω(geosimplification.TriangleArea{}.Importance(prev, current, next)).
+			Should(BeNumerically("~", s2.PointArea(prev, current, next), 1e-15))
+		Ω(geosimplification.PerpendicularDistance{}.Importance(prev, current, next)).
+			Should(BeNumerically("~", math.Sqrt2/2*math.Pi/180, 1e-5))
+		Ω(geosimplification.TurningAngle{}.Importance(prev, current, next)).
+			Should(BeNumerically("~", math.Pi/2, 1e-2))
+		Ω(geosimplification.SegmentLength{}.Importance(prev, current, next)).
+			Should(BeNumerically("~", math.Pi/180*math.Cos(math.Pi/180), 1e-5))
+		// cos θ is ~0 at a right angle
+		Ω(geosimplification.WeightedTriangleArea{K: 0.7}.Importance(prev, current, next)).
+			Should(BeNumerically("~", s2.PointArea(prev, current, next), 1e-6))
+	})
+
+	It("should rank straight on as unimportant", func() {
+		straight := point(2, 0)
+		Ω(geosimplification.TurningAngle{}.Importance(prev, current, straight)).Should(BeNumerically("~", 0, 1e-9))
+		Ω(geosimplification.PerpendicularDistance{}.Importance(prev, current, straight)).Should(BeNumerically("~", 0, 1e-9))
+	})
+
+	It("should rank vertices with a custom metric", func() {
+		line := s2.Polyline{point(0, 0), point(-1, 1), point(1, 2), point(-2, 3), point(0, 4)}
+		simplified, err := geosimplification.SimplifyLine(line, math.Inf(1), 4, false,
+			geosimplification.WithMetric(southernmost{}),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(Equal(s2.Polyline{line[0], line[1], line[2], line[4]}))
+	})
+
+	It("should match the default with TriangleArea", func() {
+		line := *s2.PolylineFromLatLngs([]s2.LatLng{
+			s2.LatLngFromDegrees(0, 0), s2.LatLngFromDegrees(0.1, 1), s2.LatLngFromDegrees(-0.3, 2),
+			s2.LatLngFromDegrees(0.2, 3), s2.LatLngFromDegrees(0, 4), s2.LatLngFromDegrees(0.05, 5),
+		})
+		plain, err := geosimplification.SimplifyLine(line, math.Inf(1), 4, true)
+		Ω(err).Should(BeNil())
+		ranked, err := geosimplification.SimplifyLine(line, math.Inf(1), 4, true,
+			geosimplification.WithMetric(geosimplification.TriangleArea{}),
+		)
+		Ω(err).Should(BeNil())
+		Ω(ranked).Should(Equal(plain))
+	})
+
+	Context("given a windowed metric", func() {
+		It("should pass the live vertices either side", func() {
+			line := s2.Polyline{
+				point(0, 0), point(-1, 1), point(1, 2), point(-2, 3), point(2, 4), point(-3, 5), point(0, 6),
+			}
+			metric := &windowRecorder{reach: 2}
+			// only the vertex south of 2.5°S goes
+			threshold := math.Pi - 2.5*math.Pi/180
+			simplified, err := geosimplification.SimplifyLine(line, threshold, 0, false,
+				geosimplification.WithWindowedMetric(metric),
+			)
+			Ω(err).Should(BeNil())
+			Ω(simplified).Should(Equal(s2.Polyline{line[0], line[1], line[2], line[3], line[4], line[6]}))
+			// shorter near the ends
+			Ω(metric.windows).Should(ContainElement(Equal([]s2.Point{line[0], line[1], line[2], line[3]})))
+			Ω(metric.windows).Should(ContainElement(Equal([]s2.Point{line[1], line[2], line[3], line[4], line[5]})))
+			// two away from the removed vertex, so re-ranked without it
+			Ω(metric.windows).Should(ContainElement(Equal([]s2.Point{line[1], line[2], line[3], line[4], line[6]})))
+		})
+
+		It("should not repeat the vertices of a loop", func() {
+			loop := s2.LoopFromPoints([]s2.Point{point(0, 0), point(0, 1), point(1, 1), point(1, 0)})
+			metric := &windowRecorder{reach: 3}
+			_, err := geosimplification.SimplifyLoop(loop, math.Inf(1), 3, false,
+				geosimplification.WithWindowedMetric(metric),
+			)
+			Ω(err).Should(BeNil())
+			Ω(metric.windows).ShouldNot(BeEmpty())
+			for _, window := range metric.windows {
+				Ω(len(window)).Should(BeNumerically("<=", 4))
+				for i := range window {
+					for j := i + 1; j < len(window); j++ {
+						Ω(window[i]).ShouldNot(Equal(window[j]))
+					}
+				}
+			}
+		})
+	})
+})
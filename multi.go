@@ -0,0 +1,320 @@
+package geosimplification
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+// Multi-part geometries are simplified by one pass of Visvalingam
+func (o *options) validateForMulti() error {
+	if o.preserveArea || o.snap > 0 || o.snapper != nil || o.orthogonal > 0 {
+		return errors.New("area preservation, snapping and building mode aren't supported for multi-part geometries")
+	}
+	if o.smoothing != NoSmoothing {
+		return errors.New("smoothing isn't supported for multi-part geometries")
+	}
+	if o.spikeAngle > 0 || o.sliverWidth > 0 {
+		return errors.New("spike and sliver removal aren't supported for multi-part geometries")
+	}
+	if o.prefilter {
+		return errors.New("prefiltering isn't supported for multi-part geometries")
+	}
+	return nil
+}
+
+// Like SimplifyLine for every part of a feature at once
+//
+// parts share one heap and one intersection index, so the least significant
+// vertex of any part is removed first (even detail across the feature), parts
+// can't cross one another, and minPointsToKeep (or KeepFraction) counts the
+// vertices of every part. Closed parts (the first point repeated last) keep
+// at least 4 points, so they stay rings
+func SimplifyMultiLine(
+	lines []s2.Polyline,
+	threshold float64,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	opts ...Option,
+) (output []s2.Polyline, err error) {
+	start := time.Now()
+	options := newOptions(opts)
+	if err := options.validateForLine(); err != nil {
+		return nil, err
+	}
+	if err := options.validateForMulti(); err != nil {
+		return nil, err
+	}
+	constraints, err := options.constraints()
+	if err != nil {
+		return nil, err
+	}
+
+	bound := s2.EmptyRect()
+	vertices := 0
+	parts := make([]internal.VertexCollection, len(lines))
+	lists := make([]*internal.PointWithTriangleList, len(lines))
+	closed := map[internal.VertexCollection]bool{}
+	for i, line := range lines {
+		bound = bound.Union(line.RectBound())
+		vertices += len(line)
+		lists[i] = internal.NewPointWithTriangleList()
+		for _, point := range line {
+			lists[i].PushBack(internal.NewPointWithTriangle(point))
+		}
+		parts[i] = lists[i]
+		if len(line) > 1 && line[0] == line[len(line)-1] {
+			closed[parts[i]] = true
+		}
+	}
+	if len(closed) > 0 {
+		constraints = append(constraints, internal.PartMinimum[s2.Point]{Min: 4, Parts: closed})
+	}
+	threshold = options.threshold(threshold, bound)
+	minPointsToKeep = options.minPoints(minPointsToKeep, vertices)
+
+	stats := internal.Stats{}
+	if vertices > minPointsToKeep {
+		stats, err = internal.VisvalingamWithGeometry[s2.Point](
+			options.geometry(),
+			internal.NewVertexParts(parts...),
+			threshold,
+			minPointsToKeep,
+			avoidIntersections,
+			constraints...,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	output = make([]s2.Polyline, len(lines))
+	report := Report{}
+	maxDeviation := s1.Angle(0)
+	for i, list := range lists {
+		output[i] = make(s2.Polyline, 0, list.Len())
+		list.Do(func(point *internal.PointWithTriangle) error {
+			output[i] = append(output[i], point.Point)
+			return nil
+		})
+		output[i] = options.densify(output[i], false)
+		if options.report != nil {
+			report = maxReport(report, LineReport(lines[i], output[i]))
+		}
+		if options.stats != nil {
+			maxDeviation = maxAngle(maxDeviation, DirectedHausdorff(lines[i], output[i]))
+		}
+	}
+
+	if options.report != nil {
+		*options.report = report
+	}
+	if options.stats != nil {
+		outputVertices := 0
+		for _, line := range output {
+			outputVertices += len(line)
+		}
+		*options.stats = newStats(stats, vertices, outputVertices, maxDeviation, start)
+	}
+	return output, nil
+}
+
+// Like SimplifyPolygon for every loop of every polygon at once
+//
+// as SimplifyMultiLine all the loops share one heap and one intersection
+// index, so shells, holes and polygons can't cross one another. Every loop
+// keeps at least 3 vertices. MaxAreaChange is a fraction of the total area
+// and WithSmallLoops records indices counting every loop of every polygon.
+// With avoidIntersections a loop MinimiseSmallLoops would make cross
+// another is left as it was simplified
+func SimplifyMultiPolygon(
+	polygons []*s2.Polygon,
+	threshold float64,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	opts ...Option,
+) (output []*s2.Polygon, err error) {
+	start := time.Now()
+	options := newOptions(opts)
+	if err := options.validateForLoop(); err != nil {
+		return nil, err
+	}
+	if err := options.validateForMulti(); err != nil {
+		return nil, err
+	}
+
+	bound := s2.EmptyRect()
+	vertices := 0
+	area := 0.0
+	for i, polygon := range polygons {
+		if err := polygon.Validate(); err != nil {
+			return nil, fmt.Errorf("polygon `%d`: %w", i, err)
+		}
+		bound = bound.Union(polygon.RectBound())
+		vertices += polygon.NumEdges()
+		area += polygon.Area()
+	}
+	for i, labelled := range options.preservedPoints {
+		inside := false
+		for _, polygon := range polygons {
+			inside = inside || polygon.ContainsPoint(labelled.Point)
+		}
+		if inside != labelled.Inside {
+			return nil, fmt.Errorf("preserved point `%d`: expected inside to be %t", i, labelled.Inside)
+		}
+	}
+	if smallLoops := options.smallLoopIndices; smallLoops != nil {
+		*smallLoops = []int{}
+	}
+
+	constraints, err := options.constraints()
+	if err != nil {
+		return nil, err
+	}
+	// a loop needs 3 vertices
+	constraints = append(constraints, internal.PartMinimum[s2.Point]{Min: 3})
+	if !math.IsInf(options.maxAreaChange, 1) {
+		constraints = append(constraints, &internal.AreaBudget{Max: options.maxAreaChange * area})
+	}
+	if options.containment != Unconstrained {
+		avoidIntersections = true
+	}
+	threshold = options.threshold(threshold, bound)
+	minPointsToKeep = options.minPoints(minPointsToKeep, vertices)
+
+	// the interior of every polygon is on the left of every loop
+	// shells CCW and holes CW, so containment and area budgets hold for holes too
+	originals := [][]*s2.Loop{}
+	rings := [][]*internal.PointWithTriangleRing{}
+	parts := []internal.VertexCollection{}
+	for _, polygon := range polygons {
+		loops := make([]*s2.Loop, polygon.NumLoops())
+		polygonRings := make([]*internal.PointWithTriangleRing, polygon.NumLoops())
+		for i, loop := range polygon.Loops() {
+			loops[i] = cloneLoop(loop)
+			loops[i].Normalize()
+			points := loops[i].Vertices()
+			if loop.IsHole() {
+				points = reversed(points)
+			}
+
+			polygonRings[i] = internal.NewPointWithTriangleRing(internal.NewPointWithTriangle(points[0]))
+			for _, vertex := range points[1:] {
+				polygonRings[i].PushBack(internal.NewPointWithTriangle(vertex))
+			}
+			parts = append(parts, polygonRings[i])
+		}
+		originals = append(originals, loops)
+		rings = append(rings, polygonRings)
+	}
+
+	stats := internal.Stats{}
+	if vertices > minPointsToKeep {
+		stats, err = internal.VisvalingamWithGeometry[s2.Point](
+			options.geometry(),
+			internal.NewVertexParts(parts...),
+			threshold,
+			minPointsToKeep,
+			avoidIntersections,
+			constraints...,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report := Report{}
+	maxDeviation := s1.Angle(0)
+	simplified := make([][]*s2.Loop, len(polygons))
+	index := s2.NewShapeIndex()
+	for p, polygon := range polygons {
+		simplified[p] = make([]*s2.Loop, polygon.NumLoops())
+		for i, ring := range rings[p] {
+			points := make([]s2.Point, 0, ring.Len())
+			ring.Do(func(point *internal.PointWithTriangle) error {
+				points = append(points, point.Point)
+				return nil
+			})
+			if polygon.Loop(i).IsHole() {
+				points = reversed(points)
+			}
+			points = options.densify(points, true)
+			simplified[p][i] = s2.LoopFromPoints(points)
+			index.Add(simplified[p][i])
+
+			if options.report != nil {
+				report = maxReport(report, LoopReport(originals[p][i], simplified[p][i]))
+			}
+			if options.stats != nil {
+				deviation := DirectedHausdorff(ClosedPolyline(originals[p][i]), ClosedPolyline(simplified[p][i]))
+				maxDeviation = maxAngle(maxDeviation, deviation)
+			}
+		}
+	}
+
+	// minimised loops are simplified on their own, keep them from crossing
+	// every other loop as the shared pass does
+	// loops they replace stay indexed but ignored, queries panic on removed shapes
+	var replaces func(simplified, minimal *s2.Loop) bool
+	if avoidIntersections {
+		replaced := map[s2.Shape]bool{}
+		replaces = func(simplified, minimal *s2.Loop) bool {
+			query := s2.NewCrossingEdgeQuery(index)
+			for i := 0; i < minimal.NumEdges(); i++ {
+				edge := minimal.Edge(i)
+				for shape := range query.CrossingsEdgeMap(edge.V0, edge.V1, s2.CrossingTypeInterior) {
+					if shape != s2.Shape(simplified) && !replaced[shape] {
+						return false
+					}
+				}
+			}
+			replaced[simplified] = true
+			index.Add(minimal)
+			return true
+		}
+	}
+
+	output = make([]*s2.Polygon, len(polygons))
+	offset := 0
+	for p, polygon := range polygons {
+		loops, err := options.removeSmallLoops(polygon, simplified[p], offset, avoidIntersections, nil, opts, replaces)
+		if err != nil {
+			return nil, fmt.Errorf("polygon `%d`: %w", p, err)
+		}
+		offset += polygon.NumLoops()
+
+		if len(loops) == 0 {
+			output[p] = s2.PolygonFromLoops([]*s2.Loop{s2.EmptyLoop()})
+		} else {
+			output[p] = s2.PolygonFromLoops(loops)
+		}
+	}
+
+	if options.report != nil {
+		*options.report = report
+	}
+	if options.stats != nil {
+		outputVertices := 0
+		outputArea := 0.0
+		for _, polygon := range output {
+			outputVertices += polygon.NumEdges()
+			outputArea += polygon.Area()
+		}
+		*options.stats = newStats(stats, vertices, outputVertices, maxDeviation, start)
+		options.stats.AreaChange = outputArea - area
+	}
+	return output, nil
+}
+
+func reversed(points []s2.Point) []s2.Point {
+	output := make([]s2.Point, len(points))
+	for i, point := range points {
+		output[len(points)-1-i] = point
+	}
+	return output
+}
@@ -0,0 +1,131 @@
+package geosimplification_test
+
+import (
+	"math"
+
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+// A line along the equator with bumps `height` degrees tall
+func bumpyLine(lat, height float64) s2.Polyline {
+	latLngs := []s2.LatLng{}
+	for i := 0; i <= 20; i++ {
+		latLngs = append(latLngs, s2.LatLngFromDegrees(lat+math.Mod(float64(i), 2)*height, float64(i)*0.1))
+	}
+	return *s2.PolylineFromLatLngs(latLngs)
+}
+
+// A polygon from lat/lng degrees
+func polygonFromDegrees(latLngs ...[2]float64) *s2.Polygon {
+	loop := s2.LoopFromPoints(points(latLngs...))
+	loop.Normalize()
+	return s2.PolygonFromLoops([]*s2.Loop{loop})
+}
+
+func polygonsCross(a, b *s2.Polygon) bool {
+	for i := 0; i < a.NumEdges(); i++ {
+		for j := 0; j < b.NumEdges(); j++ {
+			ea, eb := a.Edge(i), b.Edge(j)
+			if s2.CrossingSign(ea.V0, ea.V1, eb.V0, eb.V1) == s2.Cross {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var _ = Describe("Multi-part simplification unit tests", func() {
+
+	It("should rank vertices across every part", func() {
+		lines := []s2.Polyline{bumpyLine(0, 0.001), bumpyLine(1, 0.1)}
+		simplified, err := geosimplification.SimplifyMultiLine(
+			lines, math.Inf(1), 0, true,
+			geosimplification.KeepFraction(0.5),
+		)
+		Ω(err).Should(BeNil())
+		Ω(len(simplified[0]) + len(simplified[1])).Should(Equal(21))
+		// the flat line gives up its vertices first
+		Ω(len(simplified[0])).Should(BeNumerically("<", len(simplified[1])))
+
+		// on their own each would keep half
+		alone, err := geosimplification.SimplifyLine(lines[1], math.Inf(1), 0, true, geosimplification.KeepFraction(0.5))
+		Ω(err).Should(BeNil())
+		Ω(len(simplified[1])).Should(BeNumerically(">", len(alone)))
+	})
+
+	Context("given an island in the mouth of a bay", func() {
+		// a square with a narrow bay cut into its east side, and a triangle
+		// poking out of the bay
+		polygons := func() []*s2.Polygon {
+			return []*s2.Polygon{
+				polygonFromDegrees(
+					[2]float64{0, 0}, [2]float64{0, 0.5}, [2]float64{0, 1}, [2]float64{0.45, 1},
+					[2]float64{0.5, 0.6}, [2]float64{0.55, 1}, [2]float64{1, 1}, [2]float64{1, 0},
+				),
+				polygonFromDegrees([2]float64{0.48, 0.9}, [2]float64{0.52, 0.9}, [2]float64{0.5, 1.1}),
+			}
+		}
+		threshold := 1e-5
+
+		BeforeEach(func() {
+			ps := polygons()
+			Ω(polygonsCross(ps[0], ps[1])).Should(BeFalse())
+			// on its own the bay is filled in, across the island
+			square, err := geosimplification.SimplifyPolygon(ps[0], threshold, 0, true)
+			Ω(err).Should(BeNil())
+			Ω(polygonsCross(square, ps[1])).Should(BeTrue())
+		})
+
+		It("should not let polygons cross one another", func() {
+			simplified, err := geosimplification.SimplifyMultiPolygon(polygons(), threshold, 0, true)
+			Ω(err).Should(BeNil())
+			Ω(simplified).Should(HaveLen(2))
+			Ω(polygonsCross(simplified[0], simplified[1])).Should(BeFalse())
+			Ω(simplified[0].NumEdges()).Should(Equal(7))
+			Ω(simplified[1].NumEdges()).Should(Equal(3))
+		})
+	})
+
+	It("should not let minimised loops cross other polygons", func() {
+		polygons := []*s2.Polygon{
+			// a square with a bay cut into its west side
+			polygonFromDegrees(
+				[2]float64{0, 0}, [2]float64{0, 1}, [2]float64{1, 1}, [2]float64{1, 0},
+				[2]float64{0.6, 0}, [2]float64{0.6, 0.5}, [2]float64{0.4, 0.5}, [2]float64{0.4, 0},
+			),
+			// a long prong, poking into the bay
+			polygonFromDegrees([2]float64{0.45, -1}, [2]float64{0.45, 0.4}, [2]float64{0.55, 0.4}, [2]float64{0.55, -1}),
+		}
+		minimise := geosimplification.MinLoopArea(1, geosimplification.MinimiseSmallLoops)
+		Ω(polygonsCross(polygons[0], polygons[1])).Should(BeFalse())
+		// minimised on its own, the bay is closed across the prong
+		alone, err := geosimplification.SimplifyPolygon(polygons[0], 0, 0, true, minimise)
+		Ω(err).Should(BeNil())
+		Ω(polygonsCross(alone, polygons[1])).Should(BeTrue())
+
+		simplified, err := geosimplification.SimplifyMultiPolygon(polygons, 0, 0, true, minimise)
+		Ω(err).Should(BeNil())
+		Ω(polygonsCross(simplified[0], simplified[1])).Should(BeFalse())
+	})
+
+	It("should keep shells and holes apart", func() {
+		polygon := s2.PolygonFromLoops([]*s2.Loop{
+			jaggedSquare(0, 0, 1),
+			jaggedSquare(0.4, 0.4, 0.2),
+		})
+		stats := geosimplification.Stats{}
+		simplified, err := geosimplification.SimplifyMultiPolygon(
+			[]*s2.Polygon{polygon}, 1e-4, 0, true,
+			geosimplification.WithContainment(geosimplification.Outer),
+			geosimplification.WithStats(&stats),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified[0].NumLoops()).Should(Equal(2))
+		Ω(simplified[0].Contains(polygon)).Should(BeTrue())
+		Ω(stats.AreaChange).Should(BeNumerically(">=", 0))
+		Ω(stats.OutputVertices).Should(BeNumerically("<", stats.InputVertices))
+	})
+})
@@ -0,0 +1,93 @@
+package geosimplification
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+// Like SimplifyLine, the fewest vertices that keep every original vertex
+// within `tolerance` of the simplified line (Imai-Iri)
+//
+// optimal, but O(n³) time in the number of vertices. Lines with more than
+// `maxVertices` fall back to SimplifyLine ranking by PerpendicularDistance
+// with `tolerance` as the threshold, which is fast but not optimal, and
+// doesn't promise the tolerance
+//
+// with avoidIntersections shortcuts crossing the original line are skipped,
+// where the line comes within twice the tolerance of itself the output may
+// still cross itself. PreservePoints, KeepFraction and Prefilter aren't
+// supported
+func SimplifyOptimal(
+	polyline s2.Polyline,
+	tolerance s1.Angle,
+	maxVertices int,
+	avoidIntersections bool,
+	opts ...Option,
+) (output s2.Polyline, err error) {
+	start := time.Now()
+	options := newOptions(opts)
+	if err := options.validateForLine(); err != nil {
+		return nil, err
+	}
+	if options.metric != nil || len(options.preservedPoints) > 0 || options.keepFraction > 0 || options.prefilter {
+		return nil, errors.New("vertex metrics, preserved points, KeepFraction and Prefilter aren't supported")
+	}
+	tolerance = options.tolerance(tolerance, polyline.RectBound())
+
+	if len(polyline) > maxVertices {
+		return SimplifyLine(polyline, tolerance.Radians(), 0, avoidIntersections,
+			append(opts[:len(opts):len(opts)], WithMetric(PerpendicularDistance{}), withoutZoom)...,
+		)
+	}
+
+	allowed := func(i, j int) bool { return true }
+	crossings := 0
+	if avoidIntersections {
+		index := s2.NewShapeIndex()
+		index.Add(&polyline)
+		query := s2.NewCrossingEdgeQuery(index)
+		allowed = func(i, j int) bool {
+			for _, edge := range query.Crossings(polyline[i], polyline[j], &polyline, s2.CrossingTypeInterior) {
+				// the edges being replaced may cross the shortcut
+				if edge < i || edge >= j {
+					crossings++
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	kept := internal.ImaiIri(polyline, tolerance, allowed)
+	output = make(s2.Polyline, len(kept))
+	for i, index := range kept {
+		output[i] = polyline[index]
+	}
+	smoothed := 0
+	if output, smoothed, err = options.smooth(output, false, avoidIntersections); err != nil {
+		return nil, err
+	}
+	crossings += smoothed
+	output = options.densify(output, false)
+	if options.snap > 0 {
+		output = SnapRoundLine(output, options.snap)
+	}
+
+	if options.report != nil {
+		*options.report = LineReport(polyline, output)
+	}
+	if options.stats != nil {
+		maxDeviation := DirectedHausdorff(polyline, output)
+		*options.stats = newStats(internal.Stats{Intersections: crossings}, len(polyline), len(output), maxDeviation, start)
+	}
+	return output, nil
+}
+
+// The tolerance is already chosen, AtZoom's threshold is an area
+func withoutZoom(o *options) {
+	o.zoom = nil
+}
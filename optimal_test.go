@@ -0,0 +1,85 @@
+package geosimplification_test
+
+import (
+	"math"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+var _ = Describe("Optimal simplification unit tests", func() {
+	point := func(lat, lng float64) s2.Point {
+		return s2.PointFromLatLng(s2.LatLngFromDegrees(lat, lng))
+	}
+	// a sine wave, 0.1 degrees tall and 4 degrees long
+	wave := func(n int) s2.Polyline {
+		line := s2.Polyline{}
+		for i := 0; i < n; i++ {
+			x := 4 * float64(i) / float64(n-1)
+			line = append(line, point(0.1*math.Sin(x*math.Pi/2), x))
+		}
+		return line
+	}
+
+	It("should keep the fewest vertices within tolerance", func() {
+		line := wave(41)
+		report := geosimplification.Report{}
+		optimal, err := geosimplification.SimplifyOptimal(line, 0.02*s1.Degree, 1000, true,
+			geosimplification.WithReport(&report),
+		)
+		Ω(err).Should(BeNil())
+		Ω(report.DirectedHausdorff).Should(BeNumerically("<=", 0.02*s1.Degree))
+
+		// nothing else within tolerance does better
+		for _, greedy := range []func() (s2.Polyline, error){
+			func() (s2.Polyline, error) {
+				return geosimplification.SimplifyReumannWitkam(line, 0.02*s1.Degree, 0, true)
+			},
+			func() (s2.Polyline, error) {
+				return geosimplification.SimplifyLang(line, 0.02*s1.Degree, 40, 0, true)
+			},
+		} {
+			simplified, err := greedy()
+			Ω(err).Should(BeNil())
+			Ω(len(optimal)).Should(BeNumerically("<=", len(simplified)))
+		}
+		Ω(optimal[0]).Should(Equal(line[0]))
+		Ω(optimal[len(optimal)-1]).Should(Equal(line[40]))
+	})
+
+	It("should keep a straight line to its ends", func() {
+		line := s2.Polyline{point(0, 0), point(0, 1), point(0, 2), point(0, 3)}
+		optimal, err := geosimplification.SimplifyOptimal(line, 0.001*s1.Degree, 1000, true)
+		Ω(err).Should(BeNil())
+		Ω(optimal).Should(Equal(s2.Polyline{line[0], line[3]}))
+	})
+
+	It("should not shortcut across the line", func() {
+		// a hairpin, the way back passes close to the way out
+		line := s2.Polyline{point(0, 0), point(0.02, 1), point(0, 2), point(-0.5, 2), point(0.01, 1), point(0.005, 0.5)}
+		crossing, err := geosimplification.SimplifyOptimal(line, 0.03*s1.Degree, 1000, false)
+		Ω(err).Should(BeNil())
+		Ω(crossing).ShouldNot(ContainElement(line[1]))
+
+		simplified, err := geosimplification.SimplifyOptimal(line, 0.03*s1.Degree, 1000, true)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(ContainElement(line[1]))
+	})
+
+	It("should fall back to Visvalingam above the size limit", func() {
+		line := wave(41)
+		simplified, err := geosimplification.SimplifyOptimal(line, 0.02*s1.Degree, 10, true)
+		Ω(err).Should(BeNil())
+		Ω(len(simplified)).Should(BeNumerically("<", len(line)))
+	})
+
+	It("should fall back at the tolerance for the zoom level", func() {
+		line := wave(200)
+		simplified, err := geosimplification.SimplifyOptimal(line, 0, 10, true, geosimplification.AtZoom(10, 256, 1))
+		Ω(err).Should(BeNil())
+		Ω(len(simplified)).Should(BeNumerically("<", len(line)/2))
+	})
+})
@@ -0,0 +1,203 @@
+package geosimplification
+
+import (
+	"errors"
+	"math"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+// Optional behaviour for SimplifyLine, SimplifyLoop and SimplifyPolygon
+type Option func(*options)
+
+type options struct {
+	preservedPoints    []LabelledPoint
+	containment        Containment
+	preserveArea       bool
+	maxAreaChange      float64
+	report             *Report
+	stats              *Stats
+	zoom               *zoomTolerance
+	snap               s1.Angle
+	snapper            s2.Snapper
+	minLoopArea        float64
+	smallLoops         SmallLoops
+	smallLoopIndices   *[]int
+	keepFraction       float64
+	metric             VertexMetric
+	prefilter          bool
+	prefilterTolerance s1.Angle
+	orthogonal         s1.Angle
+	orthogonalFrame    *internal.Orthogonal
+	smoothing          Smoothing
+	smoothness         int
+	densification      *Densification
+	spikeAngle         s1.Angle
+	spikeLength        s1.Angle
+	sliverWidth        s1.Angle
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		maxAreaChange: math.Inf(1),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// A point that must stay on the same side of the shape after simplification
+// e.g: an address that must stay inside a geofence
+type LabelledPoint struct {
+	Point s2.Point
+	// Only meaningful for loops, the point must be inside (or outside) the loop
+	// lines have no inside, the point is kept on whichever side it started
+	Inside bool
+}
+
+// Reject any vertex removal that would move one of `points` to the
+// other side of the shape
+func PreservePoints(points ...LabelledPoint) Option {
+	return func(o *options) {
+		o.preservedPoints = append(o.preservedPoints, points...)
+	}
+}
+
+// How a simplified loop relates to the original
+type Containment int
+
+const (
+	// Vertices may be removed from either side of the boundary
+	Unconstrained Containment = iota
+	// Only remove vertices whose removal adds area
+	// the simplified loop always contains the original (no false negatives)
+	Outer
+	// Only remove vertices whose removal takes away area
+	// the simplified loop is always contained by the original (no false positives)
+	Inner
+)
+
+// Restrict loop simplification to only ever grow or shrink the loop
+// this implies avoiding intersections, without which there's no guarantee
+func WithContainment(containment Containment) Option {
+	return func(o *options) {
+		o.containment = containment
+	}
+}
+
+// Collapse pairs of vertices into one, placed so the loop keeps its area
+// rather than removing vertices outright
+func PreserveArea() Option {
+	return func(o *options) {
+		o.preserveArea = true
+	}
+}
+
+// Stop removing vertices that would change the area of the loop by more
+// than `fraction` of the original area, e.g: 0.01 for 1%
+func MaxAreaChange(fraction float64) Option {
+	return func(o *options) {
+		o.maxAreaChange = fraction
+	}
+}
+
+// Measure how far the simplified shape deviates from the original
+// and write the result into `report`
+func WithReport(report *Report) Option {
+	return func(o *options) {
+		o.report = report
+	}
+}
+
+// Never simplify below `fraction` of the vertices, e.g: 0.1 for 10%
+// for multi-part geometries this counts the vertices of every part
+func KeepFraction(fraction float64) Option {
+	return func(o *options) {
+		o.keepFraction = fraction
+	}
+}
+
+// Rank vertices by weighted effective area, as mapshaper's weighted
+// Visvalingam. Sharp spikes go sooner, smooth curves stay longer
+// mapshaper uses k = 0.7, see WeightedTriangleArea
+func WeightedArea(k float64) Option {
+	return WithMetric(WeightedTriangleArea{K: k})
+}
+
+// The larger of minPointsToKeep and KeepFraction of `vertices`
+func (o *options) minPoints(minPointsToKeep, vertices int) int {
+	if keep := int(math.Ceil(o.keepFraction * float64(vertices))); keep > minPointsToKeep {
+		return keep
+	}
+	return minPointsToKeep
+}
+
+// Some options only make sense for loops
+func (o *options) validateForLine() error {
+	if o.containment != Unconstrained {
+		return errors.New("containment only applies to loops")
+	}
+	if o.preserveArea || !math.IsInf(o.maxAreaChange, 1) {
+		return errors.New("area options only apply to loops")
+	}
+	if o.orthogonal > 0 {
+		return errors.New("building mode only applies to loops")
+	}
+	if o.spikeAngle > 0 || o.sliverWidth > 0 {
+		return errors.New("spike and sliver removal only apply to loops")
+	}
+	if err := o.validateZoom(); err != nil {
+		return err
+	}
+	return o.validateSmoothing()
+}
+
+// Area preservation moves vertices, constraints only understand removing them
+func (o *options) validateForLoop() error {
+	if o.preserveArea && (o.containment != Unconstrained || len(o.preservedPoints) > 0) {
+		return errors.New("area preservation can't be combined with containment or preserved points")
+	}
+	if o.preserveArea && o.metric != nil {
+		return errors.New("area preservation can't be combined with a vertex metric")
+	}
+	if err := o.validateZoom(); err != nil {
+		return err
+	}
+	if err := o.validateOrthogonal(); err != nil {
+		return err
+	}
+	if err := o.validateCleaning(); err != nil {
+		return err
+	}
+	return o.validateSmoothing()
+}
+
+// Build the constraints Visvalingam must respect
+func (o *options) constraints() ([]internal.Constraint, error) {
+	constraints := []internal.Constraint{}
+
+	if len(o.preservedPoints) > 0 {
+		points := make([]s2.Point, len(o.preservedPoints))
+		for i, labelled := range o.preservedPoints {
+			points[i] = labelled.Point
+		}
+		preserved, err := internal.NewPreservedPoints(points...)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, preserved)
+	}
+
+	// Loops are CCW by the time they reach Visvalingam, the interior on the left
+	switch o.containment {
+	case Outer:
+		constraints = append(constraints, internal.TurnConstraint{Forbidden: s2.CounterClockwise})
+	case Inner:
+		constraints = append(constraints, internal.TurnConstraint{Forbidden: s2.Clockwise})
+	}
+
+	return constraints, nil
+}
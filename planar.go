@@ -0,0 +1,42 @@
+package geosimplification
+
+import (
+	"github.com/golang/geo/r2"
+)
+
+// Like SimplifyLine, but for projected coordinates (UTM, state plane etc)
+// areas and crossings use planar maths, the threshold is an area in the
+// square of the coordinates units
+func SimplifyPlanarLine(
+	line []r2.Point,
+	threshold float64,
+	minPointsToKeep int,
+	avoidIntersections bool,
+) (output []r2.Point, err error) {
+	return SimplifyLineOf[r2.Point](line, Planar{}, threshold, minPointsToKeep, avoidIntersections)
+}
+
+// Like SimplifyLoop, but for projected coordinates
+// the ring may be open or closed (first point repeated at the end)
+// and is returned the same way
+func SimplifyPlanarRing(
+	ring []r2.Point,
+	threshold float64,
+	minPointsToKeep int,
+	avoidIntersections bool,
+) (output []r2.Point, err error) {
+	closed := len(ring) > 1 && ring[0] == ring[len(ring)-1]
+	if closed {
+		ring = ring[:len(ring)-1]
+	}
+
+	output, err = SimplifyRingOf[r2.Point](ring, Planar{}, threshold, minPointsToKeep, avoidIntersections)
+	if err != nil {
+		return nil, err
+	}
+
+	if closed && len(output) > 0 {
+		output = append(output[:len(output):len(output)], output[0])
+	}
+	return output, nil
+}
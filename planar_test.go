@@ -0,0 +1,148 @@
+package geosimplification_test
+
+import (
+	"math"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/r3"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+// brute force, the shapes under test are tiny
+func planarSelfIntersects(line []r2.Point) bool {
+	for i := 0; i < len(line)-1; i++ {
+		for j := i + 1; j < len(line)-1; j++ {
+			if (geosimplification.Planar{}).EdgesCross(line[i], line[i+1], line[j], line[j+1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var _ = Describe("Planar simplification unit tests", func() {
+
+	It("should not reduce complexity where none exists", func() {
+		input := []r2.Point{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 2}, {X: 3, Y: 3}}
+		simplified, err := geosimplification.SimplifyPlanarLine(input, 0, 0, true)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(Equal(input))
+	})
+
+	It("should remove points with a planar area below the threshold", func() {
+		input := []r2.Point{{X: 0, Y: 0}, {X: 1, Y: 0.1}, {X: 2, Y: 0}, {X: 3, Y: 5}, {X: 4, Y: 0}}
+		simplified, err := geosimplification.SimplifyPlanarLine(input, 1, 0, true)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(Equal([]r2.Point{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 3, Y: 5}, {X: 4, Y: 0}}))
+	})
+
+	Context("given a shape that might self intersect", func() {
+		input := []r2.Point{
+			{X: 0, Y: 10},
+			{X: 4, Y: 5},
+			{X: 4, Y: 0},
+			{X: -4, Y: 0},
+			{X: 3, Y: 4.5},
+			{X: -3, Y: 10},
+		}
+		threshold := 15.0
+
+		BeforeEach(func() {
+			simplified, err := geosimplification.SimplifyPlanarLine(input, threshold, 0, false)
+			Ω(err).Should(BeNil())
+			Ω(planarSelfIntersects(simplified)).Should(BeTrue())
+		})
+
+		It("should avoid intersections when simplifying", func() {
+			simplified, err := geosimplification.SimplifyPlanarLine(input, threshold, 0, true)
+			Ω(err).Should(BeNil())
+			Ω(planarSelfIntersects(simplified)).Should(BeFalse())
+		})
+	})
+
+	It("should keep closed rings closed", func() {
+		// a 100m square in UTM coordinates with a 1m bump
+		input := []r2.Point{
+			{X: 500000, Y: 4649776},
+			{X: 500100, Y: 4649776},
+			{X: 500100, Y: 4649876},
+			{X: 500050, Y: 4649877},
+			{X: 500000, Y: 4649876},
+			{X: 500000, Y: 4649776},
+		}
+		simplified, err := geosimplification.SimplifyPlanarRing(input, 100, 0, true)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(HaveLen(5))
+		Ω(simplified[0]).Should(Equal(simplified[4]))
+		Ω(simplified).ShouldNot(ContainElement(input[3]))
+	})
+
+	It("should simplify cartesian points with the same engine", func() {
+		// a line climbing a hill, the bump is small in 3D
+		input := []r3.Vector{
+			{X: 0, Y: 0, Z: 0},
+			{X: 1, Y: 0, Z: 0.1},
+			{X: 2, Y: 0, Z: 0},
+			{X: 3, Y: 0, Z: 5},
+			{X: 4, Y: 0, Z: 0},
+		}
+		simplified, err := geosimplification.SimplifyLineOf[r3.Vector](
+			input, geosimplification.Cartesian{}, 1, 0, true,
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(Equal([]r3.Vector{input[0], input[2], input[3], input[4]}))
+	})
+
+	It("should not cross in plan view at different heights", func() {
+		// the way back passes over the way out, 100 higher
+		input := []r3.Vector{
+			{X: 0, Y: 0, Z: 0},
+			{X: 5, Y: 5, Z: 0},
+			{X: 10, Y: 0, Z: 0},
+			{X: 10, Y: -10, Z: 100},
+			{X: 5, Y: -10, Z: 100},
+			{X: 5, Y: 2, Z: 100},
+		}
+		simplified, err := geosimplification.SimplifyLineOf[r3.Vector](
+			input, geosimplification.Cartesian{}, 30, 0, true,
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(ContainElement(input[1]))
+	})
+
+	Context("given a regular grid, where every area is equal", func() {
+		It("should remove the earliest vertex first", func() {
+			staircase := []r2.Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 1}, {X: 2, Y: 2}, {X: 3, Y: 2}, {X: 3, Y: 3}}
+			simplified, err := geosimplification.SimplifyPlanarLine(staircase, math.Inf(1), 5, true)
+			Ω(err).Should(BeNil())
+			Ω(simplified).Should(Equal([]r2.Point{staircase[0], staircase[3], staircase[4], staircase[5], staircase[6]}))
+		})
+
+		It("should straighten a ring's edges in order", func() {
+			// the boundary of a 3x3 grid, 8 of the 12 points are on a straight edge
+			ring := []r2.Point{
+				{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}, {X: 3, Y: 0}, {X: 3, Y: 1}, {X: 3, Y: 2},
+				{X: 3, Y: 3}, {X: 2, Y: 3}, {X: 1, Y: 3}, {X: 0, Y: 3}, {X: 0, Y: 2}, {X: 0, Y: 1},
+			}
+			simplified, err := geosimplification.SimplifyPlanarRing(ring, math.Inf(1), 8, true)
+			Ω(err).Should(BeNil())
+			Ω(simplified).Should(Equal([]r2.Point{ring[0], ring[3], ring[6], ring[7], ring[8], ring[9], ring[10], ring[11]}))
+		})
+
+		It("should give the same result every time", func() {
+			grid := []r2.Point{}
+			for x := 0.0; x < 50; x++ {
+				grid = append(grid, r2.Point{X: x, Y: math.Mod(x, 2)})
+			}
+			first, err := geosimplification.SimplifyPlanarLine(grid, math.Inf(1), 20, true)
+			Ω(err).Should(BeNil())
+			for i := 0; i < 10; i++ {
+				again, err := geosimplification.SimplifyPlanarLine(append([]r2.Point{}, grid...), math.Inf(1), 20, true)
+				Ω(err).Should(BeNil())
+				Ω(again).Should(Equal(first))
+			}
+		})
+	})
+})
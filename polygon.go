@@ -0,0 +1,230 @@
+package geosimplification
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/golang/geo/s2"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+// What MinLoopArea does with a loop that's too small
+type SmallLoops int
+
+const (
+	// Remove the loop, and any holes in it
+	DropSmallLoops SmallLoops = iota
+	// Keep the loop, but only the fewest vertices SimplifyLoop allows
+	MinimiseSmallLoops
+)
+
+// Loops (shells or holes) smaller than `area` (steradians) once simplified
+// are dropped or minimised, see SmallLoops. e.g: tiny islands at small scales
+func MinLoopArea(area float64, small SmallLoops) Option {
+	return func(o *options) {
+		o.minLoopArea = area
+		o.smallLoops = small
+	}
+}
+
+// Record the indices (into the input polygon's Loops) of the loops
+// MinLoopArea dropped or minimised
+func WithSmallLoops(indices *[]int) Option {
+	return func(o *options) {
+		o.smallLoopIndices = indices
+	}
+}
+
+// Polygon only options
+func (o *options) validateForPolygon() error {
+	if len(o.preservedPoints) > 0 {
+		return errors.New("preserved points aren't supported for polygons")
+	}
+	return o.validateForLoop()
+}
+
+// Simplify each loop of the polygon with SimplifyLoop
+// loops are simplified independently, so a shell may cross one of its holes
+// see SimplifyMultiPolygon to simplify them together
+// containment grows (or shrinks) the polygon, so holes do the opposite
+func SimplifyPolygon(
+	polygon *s2.Polygon,
+	threshold float64,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	opts ...Option,
+) (output *s2.Polygon, err error) {
+	start := time.Now()
+	options := newOptions(opts)
+	original := polygon
+	if options.prefilter {
+		loops := make([]*s2.Loop, polygon.NumLoops())
+		for i, loop := range polygon.Loops() {
+			loops[i] = s2.LoopFromPoints(withoutDuplicates(loop.Vertices(), true))
+		}
+		polygon = s2.PolygonFromLoops(loops)
+	}
+	if err := polygon.Validate(); err != nil {
+		return nil, err
+	}
+	if err := options.validateForPolygon(); err != nil {
+		return nil, err
+	}
+	if options.smallLoopIndices != nil {
+		*options.smallLoopIndices = []int{}
+	}
+	// one threshold for the whole polygon
+	threshold = options.threshold(threshold, polygon.RectBound())
+
+	// one orientation for the whole building
+	frame := options.orthogonalFrameFor(polygon.Loops()...)
+
+	report := Report{}
+	stats := Stats{}
+	simplified := make([]*s2.Loop, polygon.NumLoops())
+	for i, original := range polygon.Loops() {
+		loopReport, loopStats := Report{}, Stats{}
+		loopOpts := append(opts[:len(opts):len(opts)], forLoop(original.IsHole(), &loopReport, &loopStats), withOrthogonalFrame(frame))
+		if simplified[i], err = SimplifyLoop(cloneLoop(original), threshold, minPointsToKeep, avoidIntersections, loopOpts...); err != nil {
+			return nil, fmt.Errorf("loop `%d`: %w", i, err)
+		}
+		report = maxReport(report, loopReport)
+		stats = addStats(stats, loopStats)
+	}
+
+	loops, err := options.removeSmallLoops(polygon, simplified, 0, avoidIntersections, frame, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(loops) == 0 {
+		output = s2.PolygonFromLoops([]*s2.Loop{s2.EmptyLoop()})
+	} else {
+		output = s2.PolygonFromLoops(loops)
+	}
+	// squaring moves vertices, the loops may cross now
+	if frame != nil && avoidIntersections {
+		if err := output.Validate(); err != nil {
+			return nil, err
+		}
+		if hasCrossings(output) {
+			return nil, errors.New("squaring made the loops cross")
+		}
+	}
+
+	if options.report != nil {
+		*options.report = report
+	}
+	if options.stats != nil {
+		stats.InputVertices = original.NumEdges()
+		stats.OutputVertices = output.NumEdges()
+		stats.AreaChange = output.Area() - polygon.Area()
+		stats.Duration = time.Since(start)
+		*options.stats = stats
+	}
+	return output, nil
+}
+
+// Apply MinLoopArea (and RemoveSlivers) to `simplified`, the simplified
+// loops of `polygon`, minimised loops are simplified with `opts` as a loop
+// of the polygon in `frame`. If `replaces` is given a minimised loop is only
+// used when it says so, otherwise the simplified loop is kept
+// recorded indices are offset by `offset`, the loops of earlier polygons
+func (o *options) removeSmallLoops(
+	polygon *s2.Polygon,
+	simplified []*s2.Loop,
+	offset int,
+	avoidIntersections bool,
+	frame *internal.Orthogonal,
+	opts []Option,
+	replaces func(simplified, minimal *s2.Loop) bool,
+) ([]*s2.Loop, error) {
+	loops := []*s2.Loop{}
+	for i := 0; i < len(simplified); i++ {
+		if simplified[i].Area() >= o.minLoopArea && !o.isSliver(simplified[i]) {
+			loops = append(loops, simplified[i])
+			continue
+		}
+
+		// holes go with their shell, and islands in those holes...
+		last := i
+		if o.smallLoops == DropSmallLoops {
+			last = polygon.LastDescendant(i)
+		}
+		if o.smallLoopIndices != nil {
+			for j := i; j <= last; j++ {
+				*o.smallLoopIndices = append(*o.smallLoopIndices, offset+j)
+			}
+		}
+		if o.smallLoops == DropSmallLoops {
+			i = last
+			continue
+		}
+
+		loopOpts := append(opts[:len(opts):len(opts)], forLoop(polygon.Loop(i).IsHole(), nil, nil), withOrthogonalFrame(frame))
+		minimal, err := SimplifyLoop(cloneLoop(polygon.Loop(i)), math.Inf(1), 0, avoidIntersections, loopOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("loop `%d`: %w", i, err)
+		}
+		if replaces != nil && !replaces(simplified[i], minimal) {
+			minimal = simplified[i]
+		}
+		loops = append(loops, minimal)
+	}
+	return loops, nil
+}
+
+// The options for one loop of a polygon
+// the threshold is already chosen, reports and stats are combined after
+func forLoop(hole bool, report *Report, stats *Stats) Option {
+	return func(o *options) {
+		o.zoom = nil
+		o.report = report
+		o.stats = stats
+		if hole {
+			o.containment = flipContainment(o.containment)
+		}
+	}
+}
+
+// SimplifyLoop inverts loops in place, leave the caller's alone
+func cloneLoop(loop *s2.Loop) *s2.Loop {
+	return s2.LoopFromPoints(append([]s2.Point{}, loop.Vertices()...))
+}
+
+func flipContainment(containment Containment) Containment {
+	switch containment {
+	case Outer:
+		return Inner
+	case Inner:
+		return Outer
+	}
+	return containment
+}
+
+func maxReport(a, b Report) Report {
+	if b.DirectedHausdorff > a.DirectedHausdorff {
+		a.DirectedHausdorff = b.DirectedHausdorff
+	}
+	if b.Hausdorff > a.Hausdorff {
+		a.Hausdorff = b.Hausdorff
+	}
+	if b.Frechet > a.Frechet {
+		a.Frechet = b.Frechet
+	}
+	return a
+}
+
+func addStats(a, b Stats) Stats {
+	a.RejectedIntersections += b.RejectedIntersections
+	a.RejectedConstraints += b.RejectedConstraints
+	if b.EffectiveArea > a.EffectiveArea {
+		a.EffectiveArea = b.EffectiveArea
+	}
+	if b.MaxDeviation > a.MaxDeviation {
+		a.MaxDeviation = b.MaxDeviation
+	}
+	return a
+}
@@ -0,0 +1,128 @@
+package geosimplification_test
+
+import (
+	"math"
+
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+// A jagged CCW square, `size` degrees across with its corner at lat, lng
+func jaggedSquare(lat, lng, size float64) *s2.Loop {
+	latLngs := []s2.LatLng{}
+	steps := 10
+	jitter := func(i int) float64 {
+		return math.Mod(float64(i), 2) * size * 0.01
+	}
+	for i := 0; i < steps; i++ {
+		latLngs = append(latLngs, s2.LatLngFromDegrees(lat-jitter(i), lng+size*float64(i)/float64(steps)))
+	}
+	for i := 0; i < steps; i++ {
+		latLngs = append(latLngs, s2.LatLngFromDegrees(lat+size*float64(i)/float64(steps), lng+size+jitter(i)))
+	}
+	for i := 0; i < steps; i++ {
+		latLngs = append(latLngs, s2.LatLngFromDegrees(lat+size+jitter(i), lng+size-size*float64(i)/float64(steps)))
+	}
+	for i := 0; i < steps; i++ {
+		latLngs = append(latLngs, s2.LatLngFromDegrees(lat+size-size*float64(i)/float64(steps), lng-jitter(i)))
+	}
+	return s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+}
+
+var _ = Describe("Polygon simplification unit tests", func() {
+
+	// an island, with a lake, and islets around it
+	archipelago := func() *s2.Polygon {
+		return s2.PolygonFromLoops([]*s2.Loop{
+			jaggedSquare(0, 0, 1),
+			jaggedSquare(0.4, 0.4, 0.2),
+			jaggedSquare(2, 2, 0.01),
+			jaggedSquare(3, 3, 0.01),
+		})
+	}
+
+	It("should simplify every loop", func() {
+		polygon := archipelago()
+		simplified, err := geosimplification.SimplifyPolygon(polygon, 1e-9, 0, true)
+		Ω(err).Should(BeNil())
+		Ω(simplified.Validate()).ShouldNot(HaveOccurred())
+		Ω(simplified.NumLoops()).Should(Equal(4))
+		Ω(simplified.NumEdges()).Should(BeNumerically("<", polygon.NumEdges()))
+	})
+
+	It("should drop loops that are too small", func() {
+		polygon := archipelago()
+		small := []int{}
+		simplified, err := geosimplification.SimplifyPolygon(
+			polygon, 1e-9, 0, true,
+			geosimplification.MinLoopArea(1e-6, geosimplification.DropSmallLoops),
+			geosimplification.WithSmallLoops(&small),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified.NumLoops()).Should(Equal(2))
+		Ω(small).Should(HaveLen(2))
+		for _, i := range small {
+			Ω(polygon.Loop(i).Area()).Should(BeNumerically("<", 1e-6))
+		}
+	})
+
+	It("should drop the holes of dropped shells", func() {
+		polygon := s2.PolygonFromLoops([]*s2.Loop{
+			jaggedSquare(0, 0, 0.05),
+			jaggedSquare(0.02, 0.02, 0.01),
+		})
+		small := []int{}
+		simplified, err := geosimplification.SimplifyPolygon(
+			polygon, 1e-12, 0, true,
+			geosimplification.MinLoopArea(1e-5, geosimplification.DropSmallLoops),
+			geosimplification.WithSmallLoops(&small),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified.IsEmpty()).Should(BeTrue())
+		Ω(small).Should(Equal([]int{0, 1}))
+	})
+
+	It("should minimise loops that are too small", func() {
+		simplified, err := geosimplification.SimplifyPolygon(
+			archipelago(), 1e-12, 0, true,
+			geosimplification.MinLoopArea(1e-6, geosimplification.MinimiseSmallLoops),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified.NumLoops()).Should(Equal(4))
+		minimised := 0
+		for _, loop := range simplified.Loops() {
+			if loop.NumVertices() == 4 {
+				minimised++
+			}
+		}
+		Ω(minimised).Should(Equal(2))
+	})
+
+	It("should shrink holes when growing the polygon", func() {
+		polygon := archipelago()
+		simplified, err := geosimplification.SimplifyPolygon(
+			polygon, 1e-6, 0, true,
+			geosimplification.WithContainment(geosimplification.Outer),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified.Contains(polygon)).Should(BeTrue())
+	})
+
+	It("should keep minimised loops contained", func() {
+		polygon := s2.PolygonFromLoops([]*s2.Loop{
+			jaggedSquare(0, 0, 1),
+			jaggedSquare(0.4, 0.4, 0.01),
+			jaggedSquare(2, 2, 0.01),
+		})
+		simplified, err := geosimplification.SimplifyPolygon(
+			polygon, 1e-6, 0, true,
+			geosimplification.MinLoopArea(1e-6, geosimplification.MinimiseSmallLoops),
+			geosimplification.WithContainment(geosimplification.Outer),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified.NumLoops()).Should(Equal(3))
+		Ω(simplified.Contains(polygon)).Should(BeTrue())
+	})
+})
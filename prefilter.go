@@ -0,0 +1,62 @@
+package geosimplification
+
+import (
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+// Before simplifying, drop consecutive duplicate vertices and then any
+// vertex closer than `tolerance` to the last one kept, 0 for duplicates only
+// e.g: GPS tracks recorded while standing still
+//
+// applies to SimplifyLine, SimplifyLoop and SimplifyPolygon, the others
+// return an error. The radial pass
+// keeps the ends of lines and respects avoidIntersections and every option
+// the main pass does, e.g: PreservePoints, WithContainment, MaxAreaChange
+func Prefilter(tolerance s1.Angle) Option {
+	return func(o *options) {
+		o.prefilter = true
+		o.prefilterTolerance = tolerance
+	}
+}
+
+// Consecutive duplicates, for rings the last vertex is compared with the first
+// they're what s2 calls invalid and the rtree can't index
+func withoutDuplicates(points []s2.Point, ring bool) []s2.Point {
+	output := make([]s2.Point, 0, len(points))
+	for _, point := range points {
+		if len(output) > 0 && output[len(output)-1] == point {
+			continue
+		}
+		output = append(output, point)
+	}
+	for ring && len(output) > 1 && output[0] == output[len(output)-1] {
+		output = output[:len(output)-1]
+	}
+	return output
+}
+
+// Remove the vertices within the prefilter tolerance of one another
+// counting intersections and vetoes into `stats`
+func (o *options) radialPass(
+	vertices internal.VertexCollection,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	constraints []internal.Constraint,
+	stats *internal.Stats,
+) error {
+	if !o.prefilter || o.prefilterTolerance <= 0 {
+		return nil
+	}
+	passed, err := internal.Streaming(
+		vertices,
+		internal.RadialDistance(o.prefilterTolerance),
+		minPointsToKeep,
+		avoidIntersections,
+		constraints...,
+	)
+	stats.Intersections += passed.Intersections
+	stats.Vetoed += passed.Vetoed
+	return err
+}
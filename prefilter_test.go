@@ -0,0 +1,133 @@
+package geosimplification_test
+
+import (
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+var _ = Describe("Prefilter unit tests", func() {
+	point := func(lat, lng float64) s2.Point {
+		return s2.PointFromLatLng(s2.LatLngFromDegrees(lat, lng))
+	}
+
+	Context("given a track that stood still", func() {
+		track := s2.Polyline{
+			point(0, 0), point(0, 0), point(0.5, 1), point(0.5, 1), point(0.5, 1), point(0, 2),
+		}
+
+		It("should not index duplicate vertices", func() {
+			_, err := geosimplification.SimplifyLine(track, 1e-6, 0, true)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("should drop them first", func() {
+			stats := geosimplification.Stats{}
+			simplified, err := geosimplification.SimplifyLine(track, 1e-6, 0, true,
+				geosimplification.Prefilter(0),
+				geosimplification.WithStats(&stats),
+			)
+			Ω(err).Should(BeNil())
+			Ω(simplified).Should(Equal(s2.Polyline{point(0, 0), point(0.5, 1), point(0, 2)}))
+			Ω(stats.InputVertices).Should(Equal(6))
+		})
+	})
+
+	It("should drop vertices closer than the tolerance", func() {
+		line := s2.Polyline{point(0, 0)}
+		for i := 1; i <= 10; i++ {
+			line = append(line, point(0.001*float64(i%2), 0.001*float64(i)))
+		}
+		line = append(line, point(1, 1))
+
+		simplified, err := geosimplification.SimplifyLine(line, 0, 0, true,
+			geosimplification.Prefilter(0.0025*s1.Degree),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(Equal(s2.Polyline{line[0], line[3], line[6], line[9], line[11]}))
+	})
+
+	It("should keep preserved points on their side", func() {
+		line := s2.Polyline{point(0, 0), point(0.001, 0.001), point(0, 0.002), point(0, 1)}
+		below := point(0.0005, 0.001)
+		simplified, err := geosimplification.SimplifyLine(line, 0, 0, true,
+			geosimplification.Prefilter(0.005*s1.Degree),
+			geosimplification.PreservePoints(geosimplification.LabelledPoint{Point: below}),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(ContainElement(line[1]))
+
+		simplified, err = geosimplification.SimplifyLine(line, 0, 0, false,
+			geosimplification.Prefilter(0.005*s1.Degree),
+			geosimplification.PreservePoints(geosimplification.LabelledPoint{Point: below}),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(ContainElement(line[1]))
+	})
+
+	It("should only prefilter where it's applied", func() {
+		line := s2.Polyline{point(0, 0), point(0.001, 0.001), point(0, 1)}
+		prefilter := geosimplification.Prefilter(0.005 * s1.Degree)
+		_, err := geosimplification.SimplifyMultiLine([]s2.Polyline{line}, 0, 0, true, prefilter)
+		Ω(err).Should(HaveOccurred())
+		_, err = geosimplification.SimplifyRadialDistance(line, s1.Degree, 0, true, prefilter)
+		Ω(err).Should(HaveOccurred())
+		_, err = geosimplification.SimplifyOptimal(line, s1.Degree, 1000, true, prefilter)
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("should prefilter loops and polygons", func() {
+		vertices := []s2.Point{point(0, 0), point(0, 1), point(0, 1), point(1, 1), point(1, 0.999), point(1, 0), point(0, 0)}
+		loop := s2.LoopFromPoints(vertices)
+		Ω(loop.Validate()).ShouldNot(BeNil())
+
+		simplified, err := geosimplification.SimplifyLoop(loop, 0, 0, true,
+			geosimplification.Prefilter(0.01*s1.Degree),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified.NumVertices()).Should(Equal(4))
+
+		polygon, err := geosimplification.SimplifyPolygon(s2.PolygonFromLoops([]*s2.Loop{s2.LoopFromPoints(vertices)}), 0, 0, true,
+			geosimplification.Prefilter(0.01*s1.Degree),
+		)
+		Ω(err).Should(BeNil())
+		Ω(polygon.NumEdges()).Should(Equal(4))
+	})
+
+	It("should spend the area budget in the radial pass", func() {
+		// a square with a corner pulled out, closer than the tolerance
+		corner := point(1.003, 1.003)
+		loop := s2.LoopFromPoints([]s2.Point{point(0, 0), point(1, 0), point(1, 1), corner, point(0, 1)})
+		loop.Normalize()
+		stats := geosimplification.Stats{}
+		simplified, err := geosimplification.SimplifyLoop(loop, 0, 0, true,
+			geosimplification.Prefilter(0.01*s1.Degree),
+			geosimplification.MaxAreaChange(0.001),
+			geosimplification.WithStats(&stats),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified.NumVertices()).Should(Equal(5))
+		Ω(stats.AreaChange).Should(BeNumerically("~", 0, 0.001*loop.Area()))
+	})
+
+	It("should measure loops against the original", func() {
+		vertices := []s2.Point{point(0, 0), point(0, 1), point(0, 1), point(1, 1), point(1, 0.999), point(1, 0)}
+		loop := s2.LoopFromPoints(vertices)
+		stats := geosimplification.Stats{}
+		report := geosimplification.Report{}
+		simplified, err := geosimplification.SimplifyLoop(loop, 0, 0, true,
+			geosimplification.Prefilter(0.01*s1.Degree),
+			geosimplification.WithStats(&stats),
+			geosimplification.WithReport(&report),
+		)
+		Ω(err).Should(BeNil())
+		Ω(stats.InputVertices).Should(Equal(6))
+		Ω(stats.AreaChange).Should(BeNumerically("~", 0, 1e-9))
+		Ω(report.Hausdorff).Should(BeNumerically("<", 0.01*s1.Degree))
+		// the caller's loop is left the way round it was
+		Ω(loop.Vertices()).Should(Equal(vertices))
+		Ω(simplified.NumVertices()).Should(Equal(4))
+	})
+})
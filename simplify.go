@@ -1,6 +1,11 @@
 package geosimplification
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
 	"github.com/golang/geo/s2"
 	"gitlab.com/hcliff/geo-simplification/internal"
 )
@@ -10,11 +15,63 @@ func SimplifyLine(
 	threshold float64,
 	minPointsToKeep int,
 	avoidIntersections bool,
+	opts ...Option,
 ) (output s2.Polyline, err error) {
-	// bail out if we don't have enough points
-	if len(polyline) <= minPointsToKeep || len(polyline) <= 2 {
-		return polyline[:], nil
+	start := time.Now()
+	options := newOptions(opts)
+	if err := options.validateForLine(); err != nil {
+		return nil, err
+	}
+
+	original := polyline
+	if options.prefilter {
+		polyline = withoutDuplicates(polyline, false)
 	}
+	minPointsToKeep = options.minPoints(minPointsToKeep, len(original))
+
+	// too few points to simplify, they're still smoothed and densified
+	output = append(s2.Polyline{}, polyline...)
+	stats := internal.Stats{}
+	if len(polyline) > minPointsToKeep && len(polyline) > 2 {
+		output, stats, err = options.simplifyLine(polyline, threshold, minPointsToKeep, avoidIntersections)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rejected := 0
+	if output, rejected, err = options.smooth(output, false, avoidIntersections); err != nil {
+		return nil, err
+	}
+	stats.Intersections += rejected
+	output = options.densify(output, false)
+	if options.snap > 0 {
+		output = SnapRoundLine(output, options.snap)
+	}
+
+	if options.report != nil {
+		*options.report = LineReport(original, output)
+	}
+	if options.stats != nil {
+		maxDeviation := DirectedHausdorff(original, output)
+		*options.stats = newStats(stats, len(original), len(output), maxDeviation, start)
+	}
+
+	return output, nil
+}
+
+// Visvalingam on a line with enough points to simplify
+func (o *options) simplifyLine(
+	polyline s2.Polyline,
+	threshold float64,
+	minPointsToKeep int,
+	avoidIntersections bool,
+) (s2.Polyline, internal.Stats, error) {
+	constraints, err := o.constraints()
+	if err != nil {
+		return nil, internal.Stats{}, err
+	}
+	threshold = o.threshold(threshold, polyline.RectBound())
 
 	pointList := internal.NewPointWithTriangleList()
 	for i := range polyline {
@@ -22,23 +79,31 @@ func SimplifyLine(
 		pointList.PushBack(point)
 	}
 
-	if err := internal.Visvalingam(
+	prefiltered := internal.Stats{}
+	if err := o.radialPass(pointList, minPointsToKeep, avoidIntersections, constraints, &prefiltered); err != nil {
+		return nil, internal.Stats{}, err
+	}
+	stats, err := internal.VisvalingamWithGeometry[s2.Point](
+		o.geometry(),
 		pointList,
 		threshold,
 		minPointsToKeep,
 		avoidIntersections,
-	); err != nil {
-		return nil, err
+		constraints...,
+	)
+	if err != nil {
+		return nil, internal.Stats{}, err
 	}
+	stats.Intersections += prefiltered.Intersections
+	stats.Vetoed += prefiltered.Vetoed
 
 	// Take the resulting linked list and build the lineString
-	output = make(s2.Polyline, 0, pointList.Len())
+	output := make(s2.Polyline, 0, pointList.Len())
 	pointList.Do(func(point *internal.PointWithTriangle) error {
 		output = append(output, point.Point)
 		return nil
 	})
-
-	return output, nil
+	return output, stats, nil
 }
 
 func SimplifyLoop(
@@ -46,16 +111,57 @@ func SimplifyLoop(
 	threshold float64,
 	minPointsToKeep int,
 	avoidIntersections bool,
+	opts ...Option,
 ) (output *s2.Loop, err error) {
+	start := time.Now()
+	options := newOptions(opts)
+	original := loop
+	if options.prefilter {
+		loop = s2.LoopFromPoints(withoutDuplicates(loop.Vertices(), true))
+	}
 	if err := loop.Validate(); err != nil {
 		return nil, err
 	}
 
+	if err := options.validateForLoop(); err != nil {
+		return nil, err
+	}
+	// Preserved points are kept on their side of the loop
+	// make sure they started there
+	for i, labelled := range options.preservedPoints {
+		if loop.ContainsPoint(labelled.Point) != labelled.Inside {
+			return nil, fmt.Errorf("preserved point `%d`: expected inside to be %t", i, labelled.Inside)
+		}
+	}
+	constraints, err := options.constraints()
+	if err != nil {
+		return nil, err
+	}
+
+	threshold = options.threshold(threshold, loop.RectBound())
+
 	// We need the loop to be CW to work
 	if loop.TurningAngle() < 0 {
 		loop.Invert()
+		// the output is measured against the original, the same way round
+		if original != loop {
+			original = cloneLoop(original)
+			original.Invert()
+		}
+	}
+
+	// Growing or shrinking is only guaranteed if the loop stays simple
+	if options.containment != Unconstrained {
+		avoidIntersections = true
 	}
 
+	frame := options.orthogonalFrameFor(loop)
+	if frame != nil {
+		constraints = append(constraints, frame)
+	}
+
+	minPointsToKeep = options.minPoints(minPointsToKeep, loop.NumVertices())
+
 	// Require 4 points to keep the loop valid
 	// (double count start & finish)
 	if minPointsToKeep < 4 {
@@ -69,10 +175,39 @@ func SimplifyLoop(
 		pointRing.PushBack(point)
 	}
 
-	if err := internal.Visvalingam(pointRing, threshold, minPointsToKeep, avoidIntersections); err != nil {
+	// the budget is a fraction of the area, but measured in steradians
+	// one budget for every pass
+	var budget *internal.AreaBudget
+	if !math.IsInf(options.maxAreaChange, 1) {
+		budget = &internal.AreaBudget{Max: options.maxAreaChange * loop.Area()}
+		constraints = append(constraints, budget)
+	}
+
+	prefiltered := internal.Stats{}
+	if err := options.radialPass(pointRing, minPointsToKeep, avoidIntersections, constraints, &prefiltered); err != nil {
+		return nil, err
+	}
+	if err := options.clean(pointRing, minPointsToKeep, avoidIntersections, constraints, &prefiltered); err != nil {
 		return nil, err
 	}
 
+	var stats internal.Stats
+	if options.preserveArea {
+		// what's left of the budget after the radial pass
+		maxAreaChange := math.Inf(1)
+		if budget != nil {
+			maxAreaChange = budget.Max - math.Abs(budget.Change)
+		}
+		stats, err = internal.AreaPreserving(pointRing, threshold, minPointsToKeep, avoidIntersections, maxAreaChange)
+	} else {
+		stats, err = internal.VisvalingamWithGeometry[s2.Point](options.geometry(), pointRing, threshold, minPointsToKeep, avoidIntersections, constraints...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	stats.Intersections += prefiltered.Intersections
+	stats.Vetoed += prefiltered.Vetoed
+
 	// Take the resulting linked list and build the lineString
 	simplified := make([]s2.Point, 0, pointRing.Len())
 	pointRing.Do(func(point *internal.PointWithTriangle) error {
@@ -80,5 +215,50 @@ func SimplifyLoop(
 		return nil
 	})
 
-	return s2.LoopFromPoints(simplified), nil
+	if frame != nil {
+		if simplified, err = frame.Square(simplified); err != nil {
+			return nil, err
+		}
+	}
+	rejected := 0
+	if simplified, rejected, err = options.smooth(simplified, true, avoidIntersections); err != nil {
+		return nil, err
+	}
+	stats.Intersections += rejected
+	simplified = options.densify(simplified, true)
+
+	output = s2.LoopFromPoints(simplified)
+	if frame != nil {
+		if err := output.Validate(); err != nil {
+			return nil, err
+		}
+		if hasCrossings(output) {
+			return nil, errors.New("squaring made the loop cross itself")
+		}
+	}
+	if options.snap > 0 {
+		if output, err = SnapRoundLoop(output, options.snap); err != nil {
+			return nil, err
+		}
+	}
+	if options.snapper != nil {
+		polygon, err := AssemblePolygon(loopEdges(output), options.snapper)
+		if err != nil {
+			return nil, err
+		}
+		if polygon.NumLoops() != 1 {
+			return nil, fmt.Errorf("snapping split the loop into `%d` loops, see SimplifyLoopToPolygon", polygon.NumLoops())
+		}
+		output = polygon.Loop(0)
+	}
+	if options.report != nil {
+		*options.report = LoopReport(original, output)
+	}
+	if options.stats != nil {
+		maxDeviation := DirectedHausdorff(ClosedPolyline(original), ClosedPolyline(output))
+		*options.stats = newStats(stats, original.NumVertices(), output.NumVertices(), maxDeviation, start)
+		options.stats.AreaChange = output.Area() - original.Area()
+	}
+
+	return output, nil
 }
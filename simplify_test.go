@@ -1,6 +1,7 @@
 package geosimplification_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/golang/geo/s2"
@@ -84,6 +85,18 @@ var _ = Describe("Simplification unit tests", func() {
 			Ω(err).Should(BeNil())
 			Ω(internal.PolylineSelfIntersects(simplified)).Should(BeNil())
 		})
+
+		It("should count the intersections avoided", func() {
+			stats := geosimplification.Stats{}
+			simplified, err := geosimplification.SimplifyLine(*input, threshold, 0, true,
+				geosimplification.WithStats(&stats),
+			)
+			Ω(err).Should(BeNil())
+			Ω(stats.InputVertices).Should(Equal(len(*input)))
+			Ω(stats.OutputVertices).Should(Equal(len(simplified)))
+			Ω(stats.RejectedIntersections).Should(BeNumerically(">", 0))
+			Ω(stats.EffectiveArea).Should(BeNumerically("<", threshold))
+		})
 	})
 
 	Context("given a minimum point count", func() {
@@ -121,4 +134,232 @@ var _ = Describe("Simplification unit tests", func() {
 		})
 	})
 
+	Context("given points that must be preserved", func() {
+		// a square with a small bump along the top
+		// the bump is the least significant vertex
+		latLngs := []s2.LatLng{
+			s2.LatLngFromDegrees(0, 0),
+			s2.LatLngFromDegrees(0, 1),
+			s2.LatLngFromDegrees(1, 1),
+			s2.LatLngFromDegrees(1.1, 0.5),
+			s2.LatLngFromDegrees(1, 0),
+		}
+		inBump := s2.PointFromLatLng(s2.LatLngFromDegrees(1.05, 0.5))
+
+		var loop *s2.Loop
+		BeforeEach(func() {
+			loop = s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+		})
+
+		It("should drop the point without the option", func() {
+			simplified, err := geosimplification.SimplifyLoop(loop, 1, 4, true)
+			Ω(err).Should(BeNil())
+			Ω(simplified.ContainsPoint(inBump)).Should(BeFalse())
+		})
+
+		It("should keep the point inside the loop", func() {
+			simplified, err := geosimplification.SimplifyLoop(loop, 1, 4, true,
+				geosimplification.PreservePoints(geosimplification.LabelledPoint{
+					Point:  inBump,
+					Inside: true,
+				}),
+			)
+			Ω(err).Should(BeNil())
+			Ω(simplified.NumVertices()).Should(Equal(4))
+			Ω(simplified.ContainsPoint(inBump)).Should(BeTrue())
+		})
+
+		It("should reject points that start on the wrong side", func() {
+			_, err := geosimplification.SimplifyLoop(loop, 1, 4, true,
+				geosimplification.PreservePoints(geosimplification.LabelledPoint{
+					Point:  inBump,
+					Inside: false,
+				}),
+			)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("should keep the point on the same side of a line", func() {
+			line := *s2.PolylineFromLatLngs(latLngs)
+			simplified, err := geosimplification.SimplifyLine(line, 1, 0, true,
+				geosimplification.PreservePoints(geosimplification.LabelledPoint{
+					Point: inBump,
+				}),
+			)
+			Ω(err).Should(BeNil())
+			Ω(simplified).Should(ContainElement(line[3]))
+		})
+	})
+
+	Context("given a point to preserve under a large triangle", func() {
+		// the triangle around the pole bulges well beyond the box of its vertices
+		line := *s2.PolylineFromLatLngs([]s2.LatLng{
+			s2.LatLngFromDegrees(60, 0),
+			s2.LatLngFromDegrees(60, 120),
+			s2.LatLngFromDegrees(60, 240),
+		})
+		pole := s2.PointFromLatLng(s2.LatLngFromDegrees(90, 0))
+
+		It("should keep the point on the same side of the line", func() {
+			simplified, err := geosimplification.SimplifyLine(line, math.Inf(1), 0, true,
+				geosimplification.PreservePoints(geosimplification.LabelledPoint{
+					Point: pole,
+				}),
+			)
+			Ω(err).Should(BeNil())
+			Ω(simplified).Should(Equal(line))
+		})
+	})
+
+	Context("given a containment requirement", func() {
+		latLngs := []s2.LatLng{
+			s2.LatLngFromDegrees(43.023790000000005, -76.4486788),
+			s2.LatLngFromDegrees(43.0233744, -76.44862240000002),
+			s2.LatLngFromDegrees(43.022486900000004, -76.45023590000001),
+			s2.LatLngFromDegrees(43.02233710000001, -76.45022560000002),
+			s2.LatLngFromDegrees(43.02226590000001, -76.45063540000001),
+			s2.LatLngFromDegrees(43.022119900000014, -76.45087099999999),
+			s2.LatLngFromDegrees(43.022221, -76.4509325),
+			s2.LatLngFromDegrees(43.0218166, -76.45283279999998),
+			s2.LatLngFromDegrees(43.022172300000015, -76.4528584),
+			s2.LatLngFromDegrees(43.022603000000004, -76.4507891),
+		}
+
+		var original *s2.Loop
+		BeforeEach(func() {
+			// SimplifyLoop normalises loops, so must we
+			original = s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+			original.Normalize()
+		})
+
+		It("should only grow the loop", func() {
+			loop := s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+			simplified, err := geosimplification.SimplifyLoop(loop, 1, 0, false,
+				geosimplification.WithContainment(geosimplification.Outer),
+			)
+			Ω(err).Should(BeNil())
+			Ω(simplified.NumVertices()).Should(BeNumerically("<", original.NumVertices()))
+			Ω(simplified.Contains(original)).Should(BeTrue())
+		})
+
+		It("should only shrink the loop", func() {
+			loop := s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+			simplified, err := geosimplification.SimplifyLoop(loop, 1, 0, false,
+				geosimplification.WithContainment(geosimplification.Inner),
+			)
+			Ω(err).Should(BeNil())
+			Ω(simplified.NumVertices()).Should(BeNumerically("<", original.NumVertices()))
+			Ω(original.Contains(simplified)).Should(BeTrue())
+		})
+
+		It("should not apply to lines", func() {
+			_, err := geosimplification.SimplifyLine(*s2.PolylineFromLatLngs(latLngs), 1, 0, true,
+				geosimplification.WithContainment(geosimplification.Outer),
+			)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("given an area to preserve", func() {
+		latLngs := []s2.LatLng{
+			s2.LatLngFromDegrees(43.023790000000005, -76.4486788),
+			s2.LatLngFromDegrees(43.0233744, -76.44862240000002),
+			s2.LatLngFromDegrees(43.022486900000004, -76.45023590000001),
+			s2.LatLngFromDegrees(43.02233710000001, -76.45022560000002),
+			s2.LatLngFromDegrees(43.02226590000001, -76.45063540000001),
+			s2.LatLngFromDegrees(43.022119900000014, -76.45087099999999),
+			s2.LatLngFromDegrees(43.022221, -76.4509325),
+			s2.LatLngFromDegrees(43.0218166, -76.45283279999998),
+			s2.LatLngFromDegrees(43.022172300000015, -76.4528584),
+			s2.LatLngFromDegrees(43.022603000000004, -76.4507891),
+		}
+
+		var original *s2.Loop
+		BeforeEach(func() {
+			original = s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+			original.Normalize()
+		})
+
+		relativeChange := func(simplified *s2.Loop) float64 {
+			return math.Abs(simplified.Area()-original.Area()) / original.Area()
+		}
+
+		It("should keep the area when collapsing vertices", func() {
+			loop := s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+			simplified, err := geosimplification.SimplifyLoop(loop, 1, 5, true,
+				geosimplification.PreserveArea(),
+			)
+			Ω(err).Should(BeNil())
+			Ω(simplified.NumVertices()).Should(Equal(5))
+			Ω(simplified.Validate()).ShouldNot(HaveOccurred())
+			Ω(relativeChange(simplified)).Should(BeNumerically("<", 0.001))
+		})
+
+		It("should change the area without area preservation", func() {
+			loop := s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+			stats := geosimplification.Stats{}
+			simplified, err := geosimplification.SimplifyLoop(loop, 1, 5, true,
+				geosimplification.WithStats(&stats),
+			)
+			Ω(err).Should(BeNil())
+			Ω(relativeChange(simplified)).Should(BeNumerically(">", 0.01))
+			Ω(stats.AreaChange).Should(BeNumerically("~", simplified.Area()-original.Area(), 1e-15))
+			Ω(stats.OutputVertices).Should(Equal(5))
+		})
+
+		It("should limit the total change in area", func() {
+			loop := s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+			simplified, err := geosimplification.SimplifyLoop(loop, 1, 0, true,
+				geosimplification.MaxAreaChange(0.01),
+			)
+			Ω(err).Should(BeNil())
+			Ω(simplified.NumVertices()).Should(BeNumerically("<", original.NumVertices()))
+			Ω(relativeChange(simplified)).Should(BeNumerically("<=", 0.01))
+		})
+
+		It("should not apply to lines", func() {
+			_, err := geosimplification.SimplifyLine(*s2.PolylineFromLatLngs(latLngs), 1, 0, true,
+				geosimplification.PreserveArea(),
+			)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("given a sharp spike and a gentle bump", func() {
+		// the spike has the larger triangle, but it's far sharper
+		latLngs := []s2.LatLng{
+			s2.LatLngFromDegrees(0, 0),
+			s2.LatLngFromDegrees(0, 0.9),
+			s2.LatLngFromDegrees(1, 1),
+			s2.LatLngFromDegrees(0, 1.1),
+			s2.LatLngFromDegrees(0.04, 3),
+			s2.LatLngFromDegrees(0, 5),
+		}
+		spike := s2.PointFromLatLng(latLngs[2])
+
+		It("should remove the bump first by area", func() {
+			simplified, err := geosimplification.SimplifyLine(*s2.PolylineFromLatLngs(latLngs), 1, 5, true)
+			Ω(err).Should(BeNil())
+			Ω(simplified).Should(ContainElement(spike))
+		})
+
+		It("should remove the spike first by weighted area", func() {
+			simplified, err := geosimplification.SimplifyLine(*s2.PolylineFromLatLngs(latLngs), 1, 5, true,
+				geosimplification.WeightedArea(0.7),
+			)
+			Ω(err).Should(BeNil())
+			Ω(simplified).Should(HaveLen(5))
+			Ω(simplified).ShouldNot(ContainElement(spike))
+		})
+
+		It("should not combine with area preservation", func() {
+			loop := s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+			_, err := geosimplification.SimplifyLoop(loop, 1, 0, true,
+				geosimplification.PreserveArea(),
+				geosimplification.WeightedArea(0.7),
+			)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
 })
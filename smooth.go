@@ -0,0 +1,134 @@
+package geosimplification
+
+import (
+	"errors"
+
+	"github.com/golang/geo/s2"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+// How to smooth the simplified line, see Smooth
+type Smoothing int
+
+const (
+	NoSmoothing Smoothing = iota
+	// Cut every corner in four, `n` times. Each pass doubles the vertices
+	Chaikin
+	// A curve through every vertex, `n` edges per edge
+	CatmullRom
+	// Round every corner off with a quadratic curve, `n` edges per corner
+	// from the middle of the edge before to the middle of the edge after
+	Bezier
+)
+
+// Smooth the output of SimplifyLine, SimplifyLoop and SimplifyPolygon
+// e.g: so rivers and coastlines look natural, not jagged, at small scales
+//
+// with avoidIntersections a curve that would cross the line, or a curve
+// already drawn, isn't drawn and that part of the line is left as it was
+func Smooth(smoothing Smoothing, n int) Option {
+	return func(o *options) {
+		o.smoothing = smoothing
+		o.smoothness = n
+	}
+}
+
+// Smoothing moves the line, constraints only understand removing vertices
+func (o *options) validateSmoothing() error {
+	if o.smoothing == NoSmoothing {
+		return nil
+	}
+	if o.smoothness < 1 {
+		return errors.New("smoothing needs at least one iteration (or sample)")
+	}
+	if o.containment != Unconstrained || len(o.preservedPoints) > 0 || o.orthogonal > 0 {
+		return errors.New("smoothing can't be combined with containment, preserved points or building mode")
+	}
+	return nil
+}
+
+// Smooth `points`, a line or a ring (first point not repeated)
+// returns the smoothed points and how many curves were left undrawn
+func (o *options) smooth(points []s2.Point, ring bool, avoidIntersections bool) ([]s2.Point, int, error) {
+	if o.smoothing == NoSmoothing || len(points) < 3 {
+		return points, 0, nil
+	}
+	n := len(points)
+	at := func(i int) s2.Point {
+		if ring {
+			return points[(i+n)%n]
+		}
+		// clamp, the ends of a line have nothing beyond them
+		if i < 0 {
+			return points[0]
+		}
+		if i >= n {
+			return points[n-1]
+		}
+		return points[i]
+	}
+	edges := make([]s2.Edge, 0, n)
+	for i := 0; i < n-1 || (ring && i < n); i++ {
+		edges = append(edges, s2.Edge{V0: at(i), V1: at(i + 1)})
+	}
+
+	sections := []internal.Section{}
+	switch o.smoothing {
+	case Chaikin, Bezier:
+		// a line's ends aren't corners
+		first, last := 1, n-2
+		if ring {
+			first, last = 0, n-1
+		}
+		for i := first; i <= last; i++ {
+			before := s2.Interpolate(0.5, at(i-1), at(i))
+			after := s2.Interpolate(0.5, at(i), at(i+1))
+			var curve []s2.Point
+			if o.smoothing == Chaikin {
+				curve = internal.ChaikinCorner(before, at(i), after, o.smoothness)
+			} else {
+				curve = internal.BezierCorner(before, at(i), after, o.smoothness)
+			}
+			sections = append(sections, internal.Section{
+				Original: []s2.Point{at(i)},
+				Smoothed: curve,
+				Edges:    []int{(i - 1 + len(edges)) % len(edges), i},
+			})
+		}
+	case CatmullRom:
+		for i := range edges {
+			sections = append(sections, internal.Section{
+				Original: []s2.Point{at(i), at(i + 1)},
+				Smoothed: internal.CatmullRomEdge(at(i-1), at(i), at(i+1), at(i+2), o.smoothness),
+				Edges:    []int{i},
+			})
+		}
+	default:
+		return nil, 0, errors.New("unknown smoothing")
+	}
+
+	chosen, rejected, err := internal.ChooseSections(edges, sections, avoidIntersections)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	smoothed := []s2.Point{}
+	if !ring {
+		smoothed = append(smoothed, points[0])
+	}
+	for _, section := range chosen {
+		for _, point := range section {
+			if len(smoothed) > 0 && smoothed[len(smoothed)-1] == point {
+				continue
+			}
+			smoothed = append(smoothed, point)
+		}
+	}
+	if !ring && smoothed[len(smoothed)-1] != points[n-1] {
+		smoothed = append(smoothed, points[n-1])
+	}
+	if ring && len(smoothed) > 1 && smoothed[0] == smoothed[len(smoothed)-1] {
+		smoothed = smoothed[:len(smoothed)-1]
+	}
+	return smoothed, rejected, nil
+}
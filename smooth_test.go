@@ -0,0 +1,114 @@
+package geosimplification_test
+
+import (
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+var _ = Describe("Smoothing unit tests", func() {
+
+	// a right angle, nothing to simplify
+	corner := s2.Polyline(points([2]float64{0, 0}, [2]float64{1, 0}, [2]float64{1, 1}))
+
+	It("should cut the corner, doubling the vertices each pass", func() {
+		for iterations, expected := range map[int]int{1: 6, 2: 8, 3: 12} {
+			smoothed, err := geosimplification.SimplifyLine(corner, 0, 0, true,
+				geosimplification.Smooth(geosimplification.Chaikin, iterations),
+			)
+			Ω(err).Should(BeNil())
+			Ω(smoothed).Should(HaveLen(expected))
+			Ω(smoothed[0]).Should(Equal(corner[0]))
+			Ω(smoothed[len(smoothed)-1]).Should(Equal(corner[2]))
+			Ω(smoothed).ShouldNot(ContainElement(corner[1]))
+		}
+	})
+
+	It("should smooth lines too short to simplify", func() {
+		var stats geosimplification.Stats
+		smoothed, err := geosimplification.SimplifyLine(corner, 0, 3, true,
+			geosimplification.Smooth(geosimplification.Chaikin, 1),
+			geosimplification.WithStats(&stats),
+		)
+		Ω(err).Should(BeNil())
+		Ω(smoothed).Should(HaveLen(6))
+		Ω(smoothed).ShouldNot(ContainElement(corner[1]))
+		Ω(stats.OutputVertices).Should(Equal(6))
+	})
+
+	It("should pass through every vertex with Catmull-Rom", func() {
+		line := s2.Polyline(points([2]float64{0, 0}, [2]float64{1, 0}, [2]float64{1, 1}, [2]float64{2, 1}))
+		smoothed, err := geosimplification.SimplifyLine(line, 0, 0, true,
+			geosimplification.Smooth(geosimplification.CatmullRom, 4),
+		)
+		Ω(err).Should(BeNil())
+		Ω(smoothed).Should(HaveLen(13))
+		for _, point := range line {
+			Ω(smoothed).Should(ContainElement(point))
+		}
+	})
+
+	It("should keep a curve inside its corner", func() {
+		smoothed, err := geosimplification.SimplifyLine(corner, 0, 0, true,
+			geosimplification.Smooth(geosimplification.Bezier, 8),
+		)
+		Ω(err).Should(BeNil())
+		Ω(smoothed).Should(HaveLen(11))
+		triangle := s2.LoopFromPoints([]s2.Point{corner[0], corner[1], corner[2]})
+		triangle.Normalize()
+		// bar the ends, and the middles of the edges the curve runs between
+		for _, point := range smoothed[2 : len(smoothed)-2] {
+			Ω(triangle.ContainsPoint(point)).Should(BeTrue())
+		}
+	})
+
+	Context("given a line that passes inside a corner", func() {
+		// rounding the corner at 1, 0 would cross the last edge
+		line := s2.Polyline(points(
+			[2]float64{0, 0}, [2]float64{1, 0}, [2]float64{1, 1},
+			[2]float64{0.9, 1}, [2]float64{0.9, 0.05},
+		))
+
+		It("shouldn't draw a curve that crosses the line", func() {
+			var stats geosimplification.Stats
+			smoothed, err := geosimplification.SimplifyLine(line, 0, 0, true,
+				geosimplification.Smooth(geosimplification.Bezier, 8),
+				geosimplification.WithStats(&stats),
+			)
+			Ω(err).Should(BeNil())
+			Ω(internal.PolylineSelfIntersects(smoothed)).Should(BeNil())
+			Ω(smoothed).Should(ContainElement(line[1]))
+			Ω(stats.RejectedIntersections).Should(Equal(1))
+		})
+
+		It("should cross without avoidIntersections", func() {
+			smoothed, err := geosimplification.SimplifyLine(line, 0, 0, false,
+				geosimplification.Smooth(geosimplification.Bezier, 8),
+			)
+			Ω(err).Should(BeNil())
+			Ω(internal.PolylineSelfIntersects(smoothed)).ShouldNot(BeNil())
+		})
+	})
+
+	It("should smooth a loop all the way round", func() {
+		square := s2.LoopFromPoints(points([2]float64{0, 0}, [2]float64{0, 1}, [2]float64{1, 1}, [2]float64{1, 0}))
+		square.Normalize()
+		smoothed, err := geosimplification.SimplifyLoop(square, 0, 0, true,
+			geosimplification.Smooth(geosimplification.Chaikin, 2),
+		)
+		Ω(err).Should(BeNil())
+		Ω(smoothed.Validate()).Should(BeNil())
+		Ω(smoothed.NumVertices()).Should(Equal(20))
+		Ω(smoothed.Area()).Should(BeNumerically("<", square.Area()))
+	})
+
+	It("should reject smoothing with a constraint", func() {
+		_, err := geosimplification.SimplifyLine(corner, 0, 0, true,
+			geosimplification.Smooth(geosimplification.Chaikin, 1),
+			geosimplification.PreservePoints(geosimplification.LabelledPoint{Point: corner[1]}),
+		)
+		Ω(err).ShouldNot(BeNil())
+	})
+})
@@ -0,0 +1,121 @@
+package geosimplification
+
+import (
+	"errors"
+	"math"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+// Round vertices to multiples of `precision` without creating crossings
+// rounding alone can push a vertex over a nearby edge, snap rounding bends
+// the edge through the vertex instead. So the result may touch itself where
+// the original didn't, but never crosses itself
+//
+// rings should be closed (first point repeated)
+// parts are snapped together, so they can't cross each other either
+func SnapRoundPlanar(parts [][]r2.Point, precision float64) [][]r2.Point {
+	scaled := make([][]r2.Point, len(parts))
+	for i, part := range parts {
+		scaled[i] = make([]r2.Point, len(part))
+		for j, p := range part {
+			scaled[i][j] = p.Mul(1 / precision)
+		}
+	}
+	snapped := internal.SnapRound(scaled)
+	for _, part := range snapped {
+		for j := range part {
+			part[j] = part[j].Mul(precision)
+		}
+	}
+	return snapped
+}
+
+// Like SnapRoundPlanar, on a grid of latitudes and longitudes
+// e.g: 1e-6 * s1.Degree to write out 6 decimal places
+// edges are treated as straight in latitude and longitude, the way most
+// formats are drawn, over short edges the difference is negligible
+func SnapRoundLine(polyline s2.Polyline, precision s1.Angle) s2.Polyline {
+	if len(polyline) == 0 {
+		return polyline
+	}
+	snapped := SnapRoundPlanar([][]r2.Point{unrolledLatLngs(polyline)}, precision.Degrees())[0]
+	return pointsFromLatLngs(snapped)
+}
+
+// Like SnapRoundLine for loops
+// errors if the loop collapses, or is pinched where two edges now touch
+func SnapRoundLoop(loop *s2.Loop, precision s1.Angle) (*s2.Loop, error) {
+	// closing the ring unrolled, a loop around a pole closes a whole turn away
+	vertices := append(append([]s2.Point{}, loop.Vertices()...), loop.Vertex(0))
+	snapped := SnapRoundPlanar([][]r2.Point{unrolledLatLngs(vertices)}, precision.Degrees())[0]
+	ring := removeSpikes(snapped[:len(snapped)-1])
+	if len(ring) < 3 {
+		return nil, errors.New("loop collapsed when snapped")
+	}
+
+	output := s2.LoopFromPoints(pointsFromLatLngs(ring))
+	if err := output.Validate(); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// Round the output of SimplifyLine or SimplifyLoop with SnapRoundLine
+// or SnapRoundLoop
+func SnapTo(precision s1.Angle) Option {
+	return func(o *options) {
+		o.snap = precision
+	}
+}
+
+// X longitude, Y latitude in degrees
+// longitudes never step more than 180 degrees, they wrap instead
+func unrolledLatLngs(points []s2.Point) []r2.Point {
+	unrolled := make([]r2.Point, len(points))
+	for i, point := range points {
+		latLng := s2.LatLngFromPoint(point)
+		unrolled[i] = r2.Point{X: latLng.Lng.Degrees(), Y: latLng.Lat.Degrees()}
+		if i > 0 {
+			prev := unrolled[i-1].X
+			unrolled[i].X = prev + math.Remainder(unrolled[i].X-prev, 360)
+		}
+	}
+	return unrolled
+}
+
+func pointsFromLatLngs(latLngs []r2.Point) []s2.Point {
+	points := make([]s2.Point, len(latLngs))
+	for i, latLng := range latLngs {
+		points[i] = s2.PointFromLatLng(s2.LatLngFromDegrees(latLng.Y, latLng.X))
+	}
+	return points
+}
+
+// Snapping can fold an edge back on itself, A B A, drop the B (and an A)
+// the ring is open (first point not repeated)
+func removeSpikes(ring []r2.Point) []r2.Point {
+	for removed := true; removed && len(ring) > 2; {
+		removed = false
+		for i := range ring {
+			prev, next := ring[(i-1+len(ring))%len(ring)], ring[(i+1)%len(ring)]
+			if prev != next {
+				continue
+			}
+			// drop ring[i] and ring[i+1] (the repeated prev)
+			j := (i + 1) % len(ring)
+			kept := make([]r2.Point, 0, len(ring)-2)
+			for k := range ring {
+				if k != i && k != j {
+					kept = append(kept, ring[k])
+				}
+			}
+			ring, removed = kept, true
+			break
+		}
+	}
+	return ring
+}
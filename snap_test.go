@@ -0,0 +1,78 @@
+package geosimplification_test
+
+import (
+	"math"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+var _ = Describe("Snap rounding unit tests", func() {
+
+	// the vertex at (5.45, 0.52) sits just below the first edge
+	// rounded to (5, 1) it's above it
+	input := []r2.Point{{X: 0, Y: 0}, {X: 10, Y: 1}, {X: 6, Y: -3}, {X: 5.45, Y: 0.52}, {X: 5, Y: -3}}
+
+	BeforeEach(func() {
+		Ω(planarSelfIntersects(input)).Should(BeFalse())
+		rounded := make([]r2.Point, len(input))
+		for i, p := range input {
+			rounded[i] = r2.Point{X: math.Round(p.X), Y: math.Round(p.Y)}
+		}
+		Ω(planarSelfIntersects(rounded)).Should(BeTrue())
+	})
+
+	It("should round without creating intersections", func() {
+		snapped := geosimplification.SnapRoundPlanar([][]r2.Point{input}, 1)[0]
+		Ω(planarSelfIntersects(snapped)).Should(BeFalse())
+		// the first edge is bent through the vertex
+		Ω(snapped[:3]).Should(Equal([]r2.Point{{X: 0, Y: 0}, {X: 5, Y: 1}, {X: 10, Y: 1}}))
+		for _, p := range snapped {
+			Ω(p.X).Should(Equal(math.Round(p.X)))
+			Ω(p.Y).Should(Equal(math.Round(p.Y)))
+		}
+	})
+
+	It("should snap polylines to a grid of degrees", func() {
+		polyline := *s2.PolylineFromLatLngs([]s2.LatLng{
+			s2.LatLngFromDegrees(51.12345678, -0.12345678),
+			s2.LatLngFromDegrees(51.22345678, -0.02345678),
+		})
+		snapped := geosimplification.SnapRoundLine(polyline, 1e-6*s1.Degree)
+		Ω(snapped).Should(HaveLen(2))
+		latLng := s2.LatLngFromPoint(snapped[0])
+		Ω(latLng.Lat.Degrees()).Should(BeNumerically("~", 51.123457, 1e-9))
+		Ω(latLng.Lng.Degrees()).Should(BeNumerically("~", -0.123457, 1e-9))
+	})
+
+	It("should not go the long way round the antimeridian", func() {
+		polyline := *s2.PolylineFromLatLngs([]s2.LatLng{
+			s2.LatLngFromDegrees(0, 179.7),
+			s2.LatLngFromDegrees(0, -179.7),
+		})
+		snapped := geosimplification.SnapRoundLine(polyline, 0.5*s1.Degree)
+		Ω(snapped).Should(HaveLen(2))
+		Ω(snapped[0].Distance(snapped[1]).Degrees()).Should(BeNumerically("~", 1, 1e-9))
+	})
+
+	It("should snap the output of SimplifyLoop", func() {
+		latLngs := []s2.LatLng{}
+		for i := 0; i < 40; i++ {
+			angle := float64(i) / 40 * 2 * math.Pi
+			radius := 0.1 + math.Mod(float64(i), 2)*0.001
+			latLngs = append(latLngs, s2.LatLngFromDegrees(radius*math.Sin(angle), radius*math.Cos(angle)))
+		}
+		loop := s2.LoopFromPoints(*s2.PolylineFromLatLngs(latLngs))
+		simplified, err := geosimplification.SimplifyLoop(loop, 1e-9, 0, true, geosimplification.SnapTo(1e-3*s1.Degree))
+		Ω(err).Should(BeNil())
+		Ω(simplified.Validate()).ShouldNot(HaveOccurred())
+		for _, vertex := range simplified.Vertices() {
+			lat := s2.LatLngFromPoint(vertex).Lat.Degrees() * 1e3
+			Ω(lat).Should(BeNumerically("~", math.Round(lat), 1e-6))
+		}
+	})
+})
@@ -0,0 +1,52 @@
+package geosimplification
+
+import (
+	"time"
+
+	"github.com/golang/geo/s1"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+// What happened during simplification
+// helpful for tuning thresholds and monitoring batch jobs
+type Stats struct {
+	InputVertices  int
+	OutputVertices int
+	// Removals put off because they would have caused an intersection
+	// a vertex is counted every time it's put off
+	RejectedIntersections int
+	// Removals put off by an option, e.g: PreservePoints or WithContainment
+	RejectedConstraints int
+	// The effective area (steradians) of the last vertex removed
+	EffectiveArea float64
+	// For loops, the simplified area less the original area (steradians)
+	AreaChange float64
+	// The furthest any point of the original is from the simplified shape
+	// see DirectedHausdorff
+	MaxDeviation s1.Angle
+	Duration     time.Duration
+}
+
+// Record what happened during simplification into `stats`
+func WithStats(stats *Stats) Option {
+	return func(o *options) {
+		o.stats = stats
+	}
+}
+
+func newStats(
+	stats internal.Stats,
+	inputVertices, outputVertices int,
+	maxDeviation s1.Angle,
+	start time.Time,
+) Stats {
+	return Stats{
+		InputVertices:         inputVertices,
+		OutputVertices:        outputVertices,
+		RejectedIntersections: stats.Intersections,
+		RejectedConstraints:   stats.Vetoed,
+		EffectiveArea:         stats.EffectiveArea,
+		MaxDeviation:          maxDeviation,
+		Duration:              time.Since(start),
+	}
+}
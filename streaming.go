@@ -0,0 +1,146 @@
+package geosimplification
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	"gitlab.com/hcliff/geo-simplification/internal"
+)
+
+// Like SimplifyLine, keeping every vertex at least `tolerance` from the
+// last vertex kept. The cheapest, often a first pass before another
+func SimplifyRadialDistance(
+	polyline s2.Polyline,
+	tolerance s1.Angle,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	opts ...Option,
+) (output s2.Polyline, err error) {
+	return simplifyStreaming(polyline, tolerance, minPointsToKeep, avoidIntersections, opts, func(tolerance s1.Angle) internal.NextKey {
+		return internal.RadialDistance(tolerance)
+	})
+}
+
+// Like SimplifyLine with Reumann-Witkam, the line through a kept vertex and
+// the one after it is widened into a strip `tolerance` either side, the
+// vertices inside are dropped and the last is kept
+func SimplifyReumannWitkam(
+	polyline s2.Polyline,
+	tolerance s1.Angle,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	opts ...Option,
+) (output s2.Polyline, err error) {
+	return simplifyStreaming(polyline, tolerance, minPointsToKeep, avoidIntersections, opts, func(tolerance s1.Angle) internal.NextKey {
+		return internal.ReumannWitkam(tolerance)
+	})
+}
+
+// Like SimplifyReumannWitkam, the strip is aimed at the first vertex at
+// least `tolerance` away and ends `maxTolerance` from the kept vertex
+// so long straights are still broken up
+func SimplifyOpheim(
+	polyline s2.Polyline,
+	tolerance, maxTolerance s1.Angle,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	opts ...Option,
+) (output s2.Polyline, err error) {
+	if maxTolerance < tolerance {
+		return nil, errors.New("maxTolerance must be at least tolerance")
+	}
+	return simplifyStreaming(polyline, tolerance, minPointsToKeep, avoidIntersections, opts, func(scaled s1.Angle) internal.NextKey {
+		// AtZoom scales both tolerances
+		max := maxTolerance
+		if tolerance > 0 {
+			max = maxTolerance * scaled / tolerance
+		}
+		return internal.Opheim(scaled, max)
+	})
+}
+
+// Like SimplifyLine with Lang, looks `lookAhead` vertices ahead of the last
+// vertex kept, and backs off until every vertex between is within
+// `tolerance` of the edge to it
+func SimplifyLang(
+	polyline s2.Polyline,
+	tolerance s1.Angle,
+	lookAhead int,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	opts ...Option,
+) (output s2.Polyline, err error) {
+	if lookAhead < 1 {
+		return nil, errors.New("lookAhead must be at least 1")
+	}
+	return simplifyStreaming(polyline, tolerance, minPointsToKeep, avoidIntersections, opts, func(tolerance s1.Angle) internal.NextKey {
+		return internal.Lang(tolerance, lookAhead)
+	})
+}
+
+// Streaming algorithms don't rank vertices, so don't take a VertexMetric
+// AtZoom replaces the tolerance with ZoomTolerance
+func simplifyStreaming(
+	polyline s2.Polyline,
+	tolerance s1.Angle,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	opts []Option,
+	algorithm func(tolerance s1.Angle) internal.NextKey,
+) (output s2.Polyline, err error) {
+	start := time.Now()
+	options := newOptions(opts)
+	if err := options.validateForLine(); err != nil {
+		return nil, err
+	}
+	if options.metric != nil {
+		return nil, errors.New("vertex metrics only apply to Visvalingam")
+	}
+	if options.prefilter {
+		return nil, errors.New("prefiltering only applies to Visvalingam")
+	}
+	constraints, err := options.constraints()
+	if err != nil {
+		return nil, err
+	}
+	if len(polyline) == 0 {
+		return polyline[:], nil
+	}
+	tolerance = options.tolerance(tolerance, polyline.RectBound())
+	minPointsToKeep = options.minPoints(minPointsToKeep, len(polyline))
+
+	pointList := internal.NewPointWithTriangleList()
+	for _, point := range polyline {
+		pointList.PushBack(internal.NewPointWithTriangle(point))
+	}
+	stats, err := internal.Streaming(pointList, algorithm(tolerance), minPointsToKeep, avoidIntersections, constraints...)
+	if err != nil {
+		return nil, err
+	}
+
+	output = make(s2.Polyline, 0, pointList.Len())
+	pointList.Do(func(point *internal.PointWithTriangle) error {
+		output = append(output, point.Point)
+		return nil
+	})
+	rejected := 0
+	if output, rejected, err = options.smooth(output, false, avoidIntersections); err != nil {
+		return nil, err
+	}
+	stats.Intersections += rejected
+	output = options.densify(output, false)
+	if options.snap > 0 {
+		output = SnapRoundLine(output, options.snap)
+	}
+
+	if options.report != nil {
+		*options.report = LineReport(polyline, output)
+	}
+	if options.stats != nil {
+		maxDeviation := DirectedHausdorff(polyline, output)
+		*options.stats = newStats(stats, len(polyline), len(output), maxDeviation, start)
+	}
+	return output, nil
+}
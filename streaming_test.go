@@ -0,0 +1,133 @@
+package geosimplification_test
+
+import (
+	"math"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+var _ = Describe("Streaming simplification unit tests", func() {
+	// along the equator every 0.1 degrees, wobbling 0.0001 degrees north and south
+	wobbly := func(n int) s2.Polyline {
+		latLngs := []s2.LatLng{}
+		for i := 0; i < n; i++ {
+			latLngs = append(latLngs, s2.LatLngFromDegrees(0.0001*math.Cos(float64(i)*math.Pi), float64(i)*0.1))
+		}
+		return *s2.PolylineFromLatLngs(latLngs)
+	}
+
+	It("should keep vertices a radial distance apart", func() {
+		line := wobbly(11)
+		simplified, err := geosimplification.SimplifyRadialDistance(line, 0.25*s1.Degree, 0, true)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(Equal(s2.Polyline{line[0], line[3], line[6], line[9], line[10]}))
+	})
+
+	It("should drop vertices within the strip", func() {
+		line := append(wobbly(11), s2.PointFromLatLng(s2.LatLngFromDegrees(1, 1)))
+		simplified, err := geosimplification.SimplifyReumannWitkam(line, 0.01*s1.Degree, 0, true)
+		Ω(err).Should(BeNil())
+		// the strip is set by the first two vertices, then the corner
+		Ω(simplified).Should(Equal(s2.Polyline{line[0], line[10], line[11]}))
+	})
+
+	It("should break long straights", func() {
+		line := wobbly(11)
+		simplified, err := geosimplification.SimplifyOpheim(line, 0.01*s1.Degree, 0.45*s1.Degree, 0, true)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(Equal(s2.Polyline{line[0], line[4], line[8], line[10]}))
+
+		_, err = geosimplification.SimplifyOpheim(line, 0.5*s1.Degree, 0.01*s1.Degree, 0, true)
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("should look no further ahead than asked", func() {
+		line := wobbly(11)
+		simplified, err := geosimplification.SimplifyLang(line, 0.01*s1.Degree, 4, 0, true)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(Equal(s2.Polyline{line[0], line[4], line[8], line[10]}))
+	})
+
+	It("should honour the same options as SimplifyLine", func() {
+		line := wobbly(11)
+		stats := geosimplification.Stats{}
+		simplified, err := geosimplification.SimplifyReumannWitkam(line, 0.01*s1.Degree, 0, true,
+			geosimplification.KeepFraction(0.5),
+			geosimplification.WithStats(&stats),
+		)
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(HaveLen(6))
+		Ω(stats.OutputVertices).Should(Equal(6))
+
+		_, err = geosimplification.SimplifyRadialDistance(line, s1.Degree, 0, true,
+			geosimplification.WithMetric(geosimplification.TurningAngle{}),
+		)
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("should keep preserved points on their side without avoiding intersections", func() {
+		line := *s2.PolylineFromLatLngs([]s2.LatLng{
+			s2.LatLngFromDegrees(0, 0),
+			s2.LatLngFromDegrees(0.2, 0.5),
+			s2.LatLngFromDegrees(0, 1),
+			s2.LatLngFromDegrees(0.2, 1.5),
+			s2.LatLngFromDegrees(0, 2),
+		})
+		// under the first peak
+		preserved := geosimplification.PreservePoints(geosimplification.LabelledPoint{
+			Point: s2.PointFromLatLng(s2.LatLngFromDegrees(0.1, 0.5)),
+		})
+		simplify := map[string]func() (s2.Polyline, error){
+			"radial distance": func() (s2.Polyline, error) {
+				return geosimplification.SimplifyRadialDistance(line, s1.Degree, 0, false, preserved)
+			},
+			"Reumann-Witkam": func() (s2.Polyline, error) {
+				return geosimplification.SimplifyReumannWitkam(line, s1.Degree, 0, false, preserved)
+			},
+			"Opheim": func() (s2.Polyline, error) {
+				return geosimplification.SimplifyOpheim(line, s1.Degree, 2*s1.Degree, 0, false, preserved)
+			},
+			"Lang": func() (s2.Polyline, error) {
+				return geosimplification.SimplifyLang(line, s1.Degree, 4, 0, false, preserved)
+			},
+		}
+		for name, f := range simplify {
+			simplified, err := f()
+			Ω(err).Should(BeNil(), name)
+			Ω(simplified).Should(ContainElement(line[1]), name)
+			Ω(len(simplified)).Should(BeNumerically("<", len(line)), name)
+		}
+	})
+
+	Context("given a line that doubles back under itself", func() {
+		// dropping the vertex at 1 degree east cuts across the way back
+		line := *s2.PolylineFromLatLngs([]s2.LatLng{
+			s2.LatLngFromDegrees(0, 0),
+			s2.LatLngFromDegrees(0.02, 1),
+			s2.LatLngFromDegrees(0, 2),
+			s2.LatLngFromDegrees(-0.5, 2),
+			s2.LatLngFromDegrees(0.01, 1),
+			s2.LatLngFromDegrees(0.005, 0.5),
+		})
+
+		It("should cross itself without avoiding intersections", func() {
+			simplified, err := geosimplification.SimplifyRadialDistance(line, 1.5*s1.Degree, 0, false)
+			Ω(err).Should(BeNil())
+			Ω(simplified).ShouldNot(ContainElement(line[1]))
+		})
+
+		It("should keep the vertex when avoiding intersections", func() {
+			stats := geosimplification.Stats{}
+			simplified, err := geosimplification.SimplifyRadialDistance(line, 1.5*s1.Degree, 0, true,
+				geosimplification.WithStats(&stats),
+			)
+			Ω(err).Should(BeNil())
+			Ω(simplified).Should(ContainElement(line[1]))
+			Ω(stats.RejectedIntersections).Should(BeNumerically(">", 0))
+		})
+	})
+})
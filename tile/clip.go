@@ -0,0 +1,114 @@
+package tile
+
+import (
+	"github.com/golang/geo/r2"
+)
+
+// Clip a line to `bound`, a line leaving and re-entering becomes two parts
+// Liang-Barsky, one segment at a time
+func clipLine(line []r2.Point, bound r2.Rect) [][]r2.Point {
+	parts := [][]r2.Point{}
+	var part []r2.Point
+	for i := 0; i+1 < len(line); i++ {
+		a, b, ok := clipSegment(line[i], line[i+1], bound)
+		if !ok {
+			continue
+		}
+		if len(part) == 0 || part[len(part)-1] != a {
+			if len(part) > 1 {
+				parts = append(parts, part)
+			}
+			part = []r2.Point{a}
+		}
+		part = append(part, b)
+	}
+	if len(part) > 1 {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+func clipSegment(a, b r2.Point, bound r2.Rect) (r2.Point, r2.Point, bool) {
+	d := b.Sub(a)
+	t0, t1 := 0.0, 1.0
+	// each edge of the bound as p*t <= q
+	for _, edge := range [][2]float64{
+		{-d.X, a.X - bound.X.Lo},
+		{d.X, bound.X.Hi - a.X},
+		{-d.Y, a.Y - bound.Y.Lo},
+		{d.Y, bound.Y.Hi - a.Y},
+	} {
+		p, q := edge[0], edge[1]
+		if p == 0 {
+			if q < 0 {
+				return a, b, false
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 && t > t0 {
+			t0 = t
+		} else if p > 0 && t < t1 {
+			t1 = t
+		}
+		if t0 > t1 {
+			return a, b, false
+		}
+	}
+
+	clippedA, clippedB := a, b
+	if t0 > 0 {
+		clippedA = a.Add(d.Mul(t0))
+	}
+	if t1 < 1 {
+		clippedB = a.Add(d.Mul(t1))
+	}
+	return clippedA, clippedB, true
+}
+
+// Clip an (open) ring to `bound`
+// Sutherland-Hodgman, rings leaving and re-entering stay one ring with
+// (harmless) zero width runs along the edge of the bound
+func clipRing(ring []r2.Point, bound r2.Rect) []r2.Point {
+	type side struct {
+		inside    func(p r2.Point) bool
+		intersect func(a, b r2.Point) r2.Point
+	}
+	atX := func(x float64) func(a, b r2.Point) r2.Point {
+		return func(a, b r2.Point) r2.Point {
+			return r2.Point{X: x, Y: a.Y + (b.Y-a.Y)*(x-a.X)/(b.X-a.X)}
+		}
+	}
+	atY := func(y float64) func(a, b r2.Point) r2.Point {
+		return func(a, b r2.Point) r2.Point {
+			return r2.Point{X: a.X + (b.X-a.X)*(y-a.Y)/(b.Y-a.Y), Y: y}
+		}
+	}
+	sides := []side{
+		{func(p r2.Point) bool { return p.X >= bound.X.Lo }, atX(bound.X.Lo)},
+		{func(p r2.Point) bool { return p.X <= bound.X.Hi }, atX(bound.X.Hi)},
+		{func(p r2.Point) bool { return p.Y >= bound.Y.Lo }, atY(bound.Y.Lo)},
+		{func(p r2.Point) bool { return p.Y <= bound.Y.Hi }, atY(bound.Y.Hi)},
+	}
+
+	for _, s := range sides {
+		if len(ring) == 0 {
+			break
+		}
+		clipped := make([]r2.Point, 0, len(ring))
+		prev := ring[len(ring)-1]
+		for _, p := range ring {
+			switch {
+			case s.inside(p) && !s.inside(prev):
+				clipped = append(clipped, s.intersect(prev, p), p)
+			case s.inside(p):
+				clipped = append(clipped, p)
+			case s.inside(prev):
+				clipped = append(clipped, s.intersect(prev, p))
+			}
+			prev = p
+		}
+		ring = clipped
+	}
+	return ring
+}
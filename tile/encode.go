@@ -0,0 +1,260 @@
+package tile
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Just enough protobuf to write vector_tile.proto
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// vector_tile.proto field numbers
+const (
+	tileLayers = 3
+
+	layerVersion  = 15
+	layerName     = 1
+	layerFeatures = 2
+	layerKeys     = 3
+	layerValues   = 4
+	layerExtent   = 5
+
+	featureID       = 1
+	featureTags     = 2
+	featureType     = 3
+	featureGeometry = 4
+
+	valueString = 1
+	valueFloat  = 2
+	valueDouble = 3
+	valueUint   = 5
+	valueSint   = 6
+	valueBool   = 7
+)
+
+// Feature.GeomType
+const (
+	geomPoint   = 1
+	geomLine    = 2
+	geomPolygon = 3
+)
+
+// Geometry commands
+const (
+	commandMoveTo    = 1
+	commandLineTo    = 2
+	commandClosePath = 7
+)
+
+type buffer []byte
+
+func (b *buffer) varint(v uint64) {
+	for v >= 0x80 {
+		*b = append(*b, byte(v)|0x80)
+		v >>= 7
+	}
+	*b = append(*b, byte(v))
+}
+
+func (b *buffer) key(field, wire int) {
+	b.varint(uint64(field<<3 | wire))
+}
+
+func (b *buffer) uint(field int, v uint64) {
+	b.key(field, wireVarint)
+	b.varint(v)
+}
+
+func (b *buffer) bytes(field int, v []byte) {
+	b.key(field, wireBytes)
+	b.varint(uint64(len(v)))
+	*b = append(*b, v...)
+}
+
+func (b *buffer) packed(field int, vs []uint32) {
+	if len(vs) == 0 {
+		return
+	}
+	packed := buffer{}
+	for _, v := range vs {
+		packed.varint(uint64(v))
+	}
+	b.bytes(field, packed)
+}
+
+func (b *buffer) fixed(field, wire int, bits uint64, size int) {
+	b.key(field, wire)
+	for i := 0; i < size; i++ {
+		*b = append(*b, byte(bits>>(8*i)))
+	}
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func command(id, count int) uint32 {
+	return uint32(id&0x7 | count<<3)
+}
+
+// The Value message for a property
+func encodeValue(value interface{}) ([]byte, error) {
+	b := buffer{}
+	switch v := value.(type) {
+	case string:
+		b.bytes(valueString, []byte(v))
+	case bool:
+		b.uint(valueBool, 0)
+		if v {
+			b[len(b)-1] = 1
+		}
+	case float32:
+		b.fixed(valueFloat, wireFixed32, uint64(math.Float32bits(v)), 4)
+	case float64:
+		b.fixed(valueDouble, wireFixed64, math.Float64bits(v), 8)
+	case int:
+		b.uint(valueSint, zigzag(int64(v)))
+	case int32:
+		b.uint(valueSint, zigzag(int64(v)))
+	case int64:
+		b.uint(valueSint, zigzag(v))
+	case uint:
+		b.uint(valueUint, uint64(v))
+	case uint32:
+		b.uint(valueUint, uint64(v))
+	case uint64:
+		b.uint(valueUint, v)
+	default:
+		return nil, fmt.Errorf("unsupported property type `%T`", value)
+	}
+	return b, nil
+}
+
+// The geometry of a feature as commands, `parts` are in tile pixels
+// rings are closed with ClosePath rather than repeating the first point
+func encodeGeometry(kind int, parts [][][2]int) []uint32 {
+	commands := []uint32{}
+	cursor := [2]int{}
+	moveTo := func(p [2]int) {
+		commands = append(commands, uint32(zigzag(int64(p[0]-cursor[0]))), uint32(zigzag(int64(p[1]-cursor[1]))))
+		cursor = p
+	}
+
+	if kind == geomPoint {
+		points := 0
+		for _, part := range parts {
+			points += len(part)
+		}
+		commands = append(commands, command(commandMoveTo, points))
+		for _, part := range parts {
+			for _, p := range part {
+				moveTo(p)
+			}
+		}
+		return commands
+	}
+
+	for _, part := range parts {
+		commands = append(commands, command(commandMoveTo, 1))
+		moveTo(part[0])
+		commands = append(commands, command(commandLineTo, len(part)-1))
+		for _, p := range part[1:] {
+			moveTo(p)
+		}
+		if kind == geomPolygon {
+			commands = append(commands, command(commandClosePath, 1))
+		}
+	}
+	return commands
+}
+
+// Builds one Layer message, sharing keys and values between features
+type layerEncoder struct {
+	name     string
+	extent   int
+	features []buffer
+	keys     []string
+	keyIndex map[string]int
+	values   [][]byte
+	valIndex map[string]int
+}
+
+func newLayerEncoder(name string, extent int) *layerEncoder {
+	return &layerEncoder{
+		name:     name,
+		extent:   extent,
+		keyIndex: map[string]int{},
+		valIndex: map[string]int{},
+	}
+}
+
+func (l *layerEncoder) tags(properties map[string]interface{}) ([]uint32, error) {
+	// sorted, so the same features always make the same tile
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tags := []uint32{}
+	for _, key := range keys {
+		value := properties[key]
+		encoded, err := encodeValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("property `%s`: %w", key, err)
+		}
+		k, ok := l.keyIndex[key]
+		if !ok {
+			k = len(l.keys)
+			l.keyIndex[key] = k
+			l.keys = append(l.keys, key)
+		}
+		v, ok := l.valIndex[string(encoded)]
+		if !ok {
+			v = len(l.values)
+			l.valIndex[string(encoded)] = v
+			l.values = append(l.values, encoded)
+		}
+		tags = append(tags, uint32(k), uint32(v))
+	}
+	return tags, nil
+}
+
+func (l *layerEncoder) add(feature *Feature, kind int, parts [][][2]int) error {
+	tags, err := l.tags(feature.Properties)
+	if err != nil {
+		return err
+	}
+	b := buffer{}
+	if feature.ID != 0 {
+		b.uint(featureID, feature.ID)
+	}
+	b.packed(featureTags, tags)
+	b.uint(featureType, uint64(kind))
+	b.packed(featureGeometry, encodeGeometry(kind, parts))
+	l.features = append(l.features, b)
+	return nil
+}
+
+func (l *layerEncoder) encode() buffer {
+	b := buffer{}
+	b.uint(layerVersion, 2)
+	b.bytes(layerName, []byte(l.name))
+	for _, feature := range l.features {
+		b.bytes(layerFeatures, feature)
+	}
+	for _, key := range l.keys {
+		b.bytes(layerKeys, []byte(key))
+	}
+	for _, value := range l.values {
+		b.bytes(layerValues, value)
+	}
+	b.uint(layerExtent, uint64(l.extent))
+	return b
+}
@@ -0,0 +1,373 @@
+package tile
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/golang/geo/r2"
+	"github.com/golang/geo/s2"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+type Feature struct {
+	// Zero for no id
+	ID uint64
+	// One of s2.Point, s2.Polyline, *s2.Loop or *s2.Polygon
+	Geometry interface{}
+	// Values may be strings, bools, ints, uints or floats
+	Properties map[string]interface{}
+}
+
+type Layer struct {
+	Name     string
+	Features []Feature
+}
+
+// Optional behaviour for NewGenerator
+type Option func(*options)
+
+type options struct {
+	extent    int
+	buffer    int
+	tolerance float64
+}
+
+// The width of a tile in pixels, 4096 by default
+func Extent(extent int) Option {
+	return func(o *options) {
+		o.extent = extent
+	}
+}
+
+// How far (in pixels) geometry is kept past the edge of the tile
+// 64 by default, so strokes don't stop short at the edge
+func Buffer(buffer int) Option {
+	return func(o *options) {
+		o.buffer = buffer
+	}
+}
+
+// How much detail (in pixels) simplification may remove, 1 by default
+// see geosimplification.AtZoom
+func Tolerance(pixels float64) Option {
+	return func(o *options) {
+		o.tolerance = pixels
+	}
+}
+
+// Cuts layers of features into tiles
+//
+// Features are simplified once per zoom level, on the sphere, before they're
+// clipped to each tile. So neighbouring tiles agree where they meet, no seams
+type Generator struct {
+	layers  []Layer
+	options options
+	mutex   sync.Mutex
+	zooms   map[int][]preparedLayer
+}
+
+func NewGenerator(layers []Layer, opts ...Option) (*Generator, error) {
+	o := options{extent: 4096, buffer: 64, tolerance: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	for _, layer := range layers {
+		for i, feature := range layer.Features {
+			switch feature.Geometry.(type) {
+			case s2.Point, s2.Polyline, *s2.Loop, *s2.Polygon:
+			default:
+				return nil, fmt.Errorf("layer `%s` feature `%d`: unsupported geometry `%T`", layer.Name, i, feature.Geometry)
+			}
+		}
+	}
+	return &Generator{
+		layers:  layers,
+		options: o,
+		zooms:   map[int][]preparedLayer{},
+	}, nil
+}
+
+// A geometry projected onto the map at one zoom level
+// in pixels from the top left of the world
+type shape struct {
+	kind  int
+	parts [][]r2.Point
+	// polygons only, which parts are holes
+	holes []bool
+	bound r2.Rect
+}
+
+type preparedFeature struct {
+	feature *Feature
+	shape   shape
+}
+
+type preparedLayer struct {
+	name     string
+	features []preparedFeature
+}
+
+// The features simplified and projected for zoom `z`, computed once
+func (g *Generator) prepare(z int) ([]preparedLayer, error) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if layers, ok := g.zooms[z]; ok {
+		return layers, nil
+	}
+
+	atZoom := geosimplification.AtZoom(z, g.options.extent, g.options.tolerance)
+	layers := make([]preparedLayer, len(g.layers))
+	for l, layer := range g.layers {
+		layers[l] = preparedLayer{name: layer.Name, features: make([]preparedFeature, len(layer.Features))}
+		for i := range layer.Features {
+			feature := &layer.Features[i]
+			simplified, err := simplify(feature.Geometry, atZoom)
+			if err != nil {
+				return nil, fmt.Errorf("layer `%s` feature `%d`: %w", layer.Name, i, err)
+			}
+			layers[l].features[i] = preparedFeature{
+				feature: feature,
+				shape:   newShape(simplified, z, g.options.extent),
+			}
+		}
+	}
+	g.zooms[z] = layers
+	return layers, nil
+}
+
+// SimplifyLoop inverts loops in place, leave the caller's alone
+func cloneLoop(loop *s2.Loop) *s2.Loop {
+	return s2.LoopFromPoints(append([]s2.Point{}, loop.Vertices()...))
+}
+
+func simplify(geometry interface{}, atZoom geosimplification.Option) (interface{}, error) {
+	switch g := geometry.(type) {
+	case s2.Polyline:
+		return geosimplification.SimplifyLine(g, 0, 0, true, atZoom)
+	case *s2.Loop:
+		return geosimplification.SimplifyLoop(cloneLoop(g), 0, 0, true, atZoom)
+	case *s2.Polygon:
+		return geosimplification.SimplifyPolygon(g, 0, 0, true, atZoom)
+	}
+	return geometry, nil
+}
+
+func newShape(geometry interface{}, z, extent int) shape {
+	s := shape{bound: r2.EmptyRect()}
+	add := func(ring []geosimplification.Position, hole bool) {
+		part := make([]r2.Point, len(ring))
+		for i, position := range ring {
+			part[i] = project(position, z, extent)
+			s.bound = s.bound.AddPoint(part[i])
+		}
+		s.parts = append(s.parts, part)
+		s.holes = append(s.holes, hole)
+	}
+	// GeoJSON splits everything at the antimeridian for us
+	addLoop := func(loop *s2.Loop, hole bool) {
+		for _, polygon := range geosimplification.LoopToMultiPolygon(loop) {
+			// the last position repeats the first
+			add(polygon[0][:len(polygon[0])-1], hole)
+		}
+	}
+
+	switch g := geometry.(type) {
+	case s2.Point:
+		s.kind = geomPoint
+		latLng := s2.LatLngFromPoint(g)
+		add([]geosimplification.Position{{latLng.Lng.Degrees(), latLng.Lat.Degrees()}}, false)
+	case s2.Polyline:
+		s.kind = geomLine
+		for _, line := range geosimplification.PolylineToMultiLineString(g) {
+			add(line, false)
+		}
+	case *s2.Loop:
+		s.kind = geomPolygon
+		addLoop(g, false)
+	case *s2.Polygon:
+		s.kind = geomPolygon
+		for _, loop := range g.Loops() {
+			addLoop(loop, loop.IsHole())
+		}
+	}
+	return s
+}
+
+// Encode tile `t` as a Mapbox Vector Tile
+func (g *Generator) Tile(t Tile) ([]byte, error) {
+	layers, err := g.prepare(t.Z)
+	if err != nil {
+		return nil, err
+	}
+
+	extent, margin := float64(g.options.extent), float64(g.options.buffer)
+	bound := r2.RectFromPoints(r2.Point{X: -margin, Y: -margin}, r2.Point{X: extent + margin, Y: extent + margin})
+	origin := t.origin(g.options.extent)
+	worldBound := r2.RectFromPoints(bound.Lo().Add(origin), bound.Hi().Add(origin))
+
+	tile := buffer{}
+	for _, layer := range layers {
+		encoder := newLayerEncoder(layer.name, g.options.extent)
+		for _, prepared := range layer.features {
+			if !prepared.shape.bound.Intersects(worldBound) {
+				continue
+			}
+			parts := prepared.shape.quantize(origin, bound)
+			if len(parts) == 0 {
+				continue
+			}
+			if err := encoder.add(prepared.feature, prepared.shape.kind, parts); err != nil {
+				return nil, fmt.Errorf("layer `%s`: %w", layer.name, err)
+			}
+		}
+		if len(encoder.features) > 0 {
+			tile.bytes(tileLayers, encoder.encode())
+		}
+	}
+	return tile, nil
+}
+
+// Clip the shape to `bound` and snap round it to whole tile pixels
+// rounding alone would let edges cross, see SnapRoundPlanar
+func (s shape) quantize(origin r2.Point, bound r2.Rect) [][][2]int {
+	local := func(part []r2.Point) []r2.Point {
+		translated := make([]r2.Point, len(part))
+		for i, p := range part {
+			translated[i] = p.Sub(origin)
+		}
+		return translated
+	}
+
+	switch s.kind {
+	case geomPoint:
+		points := [][2]int{}
+		for _, part := range s.parts {
+			for _, p := range local(part) {
+				if bound.ContainsPoint(p) {
+					points = append(points, toInts(p))
+				}
+			}
+		}
+		if len(points) == 0 {
+			return nil
+		}
+		return [][][2]int{points}
+
+	case geomLine:
+		clipped := [][]r2.Point{}
+		for _, part := range s.parts {
+			clipped = append(clipped, clipLine(local(part), bound)...)
+		}
+		parts := [][][2]int{}
+		for _, part := range geosimplification.SnapRoundPlanar(clipped, 1) {
+			if len(part) > 1 {
+				parts = append(parts, ints(part))
+			}
+		}
+		return parts
+	}
+
+	// rings are snapped closed, all together so they can't cross each other
+	clipped := make([][]r2.Point, len(s.parts))
+	for i, part := range s.parts {
+		if ring := clipRing(local(part), bound); len(ring) > 0 {
+			clipped[i] = append(ring, ring[0])
+		}
+	}
+	snapped := geosimplification.SnapRoundPlanar(clipped, 1)
+
+	shells, holes := [][][2]int{}, [][][2]int{}
+	for i, part := range snapped {
+		if len(part) < 4 {
+			continue
+		}
+		ring := ints(part[:len(part)-1])
+		area := surveyorArea(ring)
+		if area == 0 {
+			continue
+		}
+		// MVT shells have positive area (clockwise on screen), holes negative
+		if (area > 0) == s.holes[i] {
+			reverse(ring)
+		}
+		if s.holes[i] {
+			holes = append(holes, ring)
+		} else {
+			shells = append(shells, ring)
+		}
+	}
+
+	// each hole follows the shell it's in
+	shellHoles := make([][][][2]int, len(shells))
+	for _, hole := range holes {
+		for i, shell := range shells {
+			if ringContains(shell, interiorPoint(hole, bound)) {
+				shellHoles[i] = append(shellHoles[i], hole)
+				break
+			}
+		}
+	}
+	rings := [][][2]int{}
+	for i, shell := range shells {
+		rings = append(rings, shell)
+		rings = append(rings, shellHoles[i]...)
+	}
+	return rings
+}
+
+// Snapped points are already whole pixels
+func toInts(p r2.Point) [2]int {
+	return [2]int{int(math.Round(p.X)), int(math.Round(p.Y))}
+}
+
+func ints(points []r2.Point) [][2]int {
+	rounded := make([][2]int, len(points))
+	for i, p := range points {
+		rounded[i] = toInts(p)
+	}
+	return rounded
+}
+
+// Twice the signed area, positive is clockwise with Y growing down
+func surveyorArea(ring [][2]int) int {
+	area := 0
+	for i := range ring {
+		a, b := ring[i], ring[(i+1)%len(ring)]
+		area += a[0]*b[1] - b[0]*a[1]
+	}
+	return area
+}
+
+func reverse(ring [][2]int) {
+	for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+		ring[i], ring[j] = ring[j], ring[i]
+	}
+}
+
+func toPoint(p [2]int) r2.Point {
+	return r2.Point{X: float64(p[0]), Y: float64(p[1])}
+}
+
+// A vertex of the ring off the edge of the bound if there is one
+// clipping puts vertices of holes on shared edges of the tile
+func interiorPoint(ring [][2]int, bound r2.Rect) r2.Point {
+	for _, p := range ring {
+		if bound.InteriorContainsPoint(toPoint(p)) {
+			return toPoint(p)
+		}
+	}
+	return toPoint(ring[0])
+}
+
+// Even-odd rule
+func ringContains(ring [][2]int, p r2.Point) bool {
+	inside := false
+	for i := range ring {
+		a, b := toPoint(ring[i]), toPoint(ring[(i+1)%len(ring)])
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < a.X+(p.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,34 @@
+// Mapbox Vector Tiles, simplified once per zoom level
+// https://github.com/mapbox/vector-tile-spec/tree/master/2.1
+package tile
+
+import (
+	"math"
+
+	"github.com/golang/geo/r2"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+// A tile in the XYZ (slippy map) scheme, Y grows southwards
+type Tile struct {
+	Z, X, Y int
+}
+
+// Web Mercator can't show anything past ~85.05 degrees
+var maxLatitude = math.Atan(math.Sinh(math.Pi))
+
+// Where a position lands on the map at zoom `z`, in pixels from the top
+// left of the world, for tiles `extent` pixels wide
+func project(position geosimplification.Position, z, extent int) r2.Point {
+	scale := float64(extent) * math.Exp2(float64(z))
+	lat := math.Max(-maxLatitude, math.Min(maxLatitude, position[1]*math.Pi/180))
+	return r2.Point{
+		X: (position[0] + 180) / 360 * scale,
+		Y: (1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2 * scale,
+	}
+}
+
+// The tile's top left corner, in pixels from the top left of the world
+func (t Tile) origin(extent int) r2.Point {
+	return r2.Point{X: float64(t.X * extent), Y: float64(t.Y * extent)}
+}
@@ -0,0 +1,200 @@
+package tile_test
+
+import (
+	"math"
+
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gitlab.com/hcliff/geo-simplification/tile"
+)
+
+// Just enough protobuf to read the tiles back
+// varints as uint64, everything length delimited as []byte
+func decode(b []byte) map[int][]interface{} {
+	varint := func() uint64 {
+		v, shift := uint64(0), 0
+		for {
+			c := b[0]
+			b = b[1:]
+			v |= uint64(c&0x7f) << shift
+			if c < 0x80 {
+				return v
+			}
+			shift += 7
+		}
+	}
+	fields := map[int][]interface{}{}
+	for len(b) > 0 {
+		key := varint()
+		field := int(key >> 3)
+		switch key & 0x7 {
+		case 0:
+			fields[field] = append(fields[field], varint())
+		case 2:
+			length := varint()
+			fields[field] = append(fields[field], b[:length])
+			b = b[length:]
+		case 1:
+			fields[field] = append(fields[field], b[:8])
+			b = b[8:]
+		case 5:
+			fields[field] = append(fields[field], b[:4])
+			b = b[4:]
+		}
+	}
+	return fields
+}
+
+func packed(b []byte) []uint64 {
+	values := []uint64{}
+	for len(b) > 0 {
+		v, shift := uint64(0), 0
+		for {
+			c := b[0]
+			b = b[1:]
+			v |= uint64(c&0x7f) << shift
+			if c < 0x80 {
+				break
+			}
+			shift += 7
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// The decoded features of the only layer in the tile
+func features(t []byte) []map[int][]interface{} {
+	layers := decode(t)[3]
+	Ω(layers).Should(HaveLen(1))
+	features := []map[int][]interface{}{}
+	for _, feature := range decode(layers[0].([]byte))[2] {
+		features = append(features, decode(feature.([]byte)))
+	}
+	return features
+}
+
+// The absolute positions a geometry visits, split by MoveTo
+func positions(feature map[int][]interface{}) [][][2]int64 {
+	commands := packed(feature[4][0].([]byte))
+	parts := [][][2]int64{}
+	x, y := int64(0), int64(0)
+	unzigzag := func(v uint64) int64 {
+		return int64(v>>1) ^ -int64(v&1)
+	}
+	for i := 0; i < len(commands); {
+		id, count := commands[i]&0x7, int(commands[i]>>3)
+		i++
+		if id == 7 {
+			continue
+		}
+		for j := 0; j < count; j++ {
+			x += unzigzag(commands[i])
+			y += unzigzag(commands[i+1])
+			i += 2
+			if id == 1 {
+				parts = append(parts, [][2]int64{})
+			}
+			parts[len(parts)-1] = append(parts[len(parts)-1], [2]int64{x, y})
+		}
+	}
+	return parts
+}
+
+func newGenerator(geometry interface{}, opts ...tile.Option) *tile.Generator {
+	generator, err := tile.NewGenerator([]tile.Layer{{
+		Name: "test",
+		Features: []tile.Feature{{
+			ID:         1,
+			Geometry:   geometry,
+			Properties: map[string]interface{}{"name": "test", "rank": 3},
+		}},
+	}}, opts...)
+	Ω(err).Should(BeNil())
+	return generator
+}
+
+var _ = Describe("Vector tile unit tests", func() {
+
+	It("should encode a point", func() {
+		point := s2.PointFromLatLng(s2.LatLngFromDegrees(0, 0))
+		t, err := newGenerator(point).Tile(tile.Tile{Z: 0, X: 0, Y: 0})
+		Ω(err).Should(BeNil())
+
+		layer := decode(decode(t)[3][0].([]byte))
+		Ω(string(layer[1][0].([]byte))).Should(Equal("test"))
+		Ω(layer[5][0]).Should(Equal(uint64(4096)))
+		Ω(layer[3]).Should(HaveLen(2))
+
+		fs := features(t)
+		Ω(fs).Should(HaveLen(1))
+		Ω(fs[0][1][0]).Should(Equal(uint64(1)))
+		Ω(fs[0][3][0]).Should(Equal(uint64(1)))
+		Ω(positions(fs[0])).Should(Equal([][][2]int64{{{2048, 2048}}}))
+	})
+
+	It("should reject geometry it can't encode", func() {
+		_, err := tile.NewGenerator([]tile.Layer{{Features: []tile.Feature{{Geometry: "nope"}}}})
+		Ω(err).ShouldNot(BeNil())
+	})
+
+	It("should leave no seams between tiles", func() {
+		// a wiggly line across the boundary between the two tiles at zoom 1
+		latLngs := []s2.LatLng{}
+		for i := 0; i <= 200; i++ {
+			latLngs = append(latLngs, s2.LatLngFromDegrees(10+math.Sin(float64(i)/3)*2, -50+float64(i)/2))
+		}
+		generator := newGenerator(*s2.PolylineFromLatLngs(latLngs), tile.Buffer(0))
+
+		west, err := generator.Tile(tile.Tile{Z: 1, X: 0, Y: 0})
+		Ω(err).Should(BeNil())
+		east, err := generator.Tile(tile.Tile{Z: 1, X: 1, Y: 0})
+		Ω(err).Should(BeNil())
+
+		westParts, eastParts := positions(features(west)[0]), positions(features(east)[0])
+		Ω(westParts).Should(HaveLen(1))
+		Ω(eastParts).Should(HaveLen(1))
+		end := westParts[0][len(westParts[0])-1]
+		start := eastParts[0][0]
+		Ω(end[0]).Should(Equal(int64(4096)))
+		Ω(start[0]).Should(Equal(int64(0)))
+		Ω(end[1]).Should(Equal(start[1]))
+	})
+
+	It("should simplify more at lower zoom levels", func() {
+		latLngs := []s2.LatLng{}
+		for i := 0; i <= 200; i++ {
+			latLngs = append(latLngs, s2.LatLngFromDegrees(10+math.Sin(float64(i))*0.01, 10+float64(i)*0.00005))
+		}
+		generator := newGenerator(*s2.PolylineFromLatLngs(latLngs))
+
+		low, err := generator.Tile(tile.Tile{Z: 4, X: 8, Y: 7})
+		Ω(err).Should(BeNil())
+		high, err := generator.Tile(tile.Tile{Z: 14, X: 8647, Y: 7734})
+		Ω(err).Should(BeNil())
+		Ω(len(positions(features(low)[0])[0])).Should(BeNumerically("<", len(positions(features(high)[0])[0])))
+	})
+
+	It("should wind polygons the way MVT expects", func() {
+		loop := s2.LoopFromPoints(*s2.PolylineFromLatLngs([]s2.LatLng{
+			s2.LatLngFromDegrees(-10, -10),
+			s2.LatLngFromDegrees(-10, 10),
+			s2.LatLngFromDegrees(10, 10),
+			s2.LatLngFromDegrees(10, -10),
+		}))
+		t, err := newGenerator(loop).Tile(tile.Tile{Z: 0, X: 0, Y: 0})
+		Ω(err).Should(BeNil())
+
+		fs := features(t)
+		Ω(fs[0][3][0]).Should(Equal(uint64(3)))
+		rings := positions(fs[0])
+		Ω(rings).Should(HaveLen(1))
+		area := int64(0)
+		for i, a := range rings[0] {
+			b := rings[0][(i+1)%len(rings[0])]
+			area += a[0]*b[1] - b[0]*a[1]
+		}
+		Ω(area).Should(BeNumerically(">", 0))
+	})
+})
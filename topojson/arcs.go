@@ -0,0 +1,134 @@
+package topojson
+
+import (
+	"fmt"
+
+	"github.com/golang/geo/s2"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+// Simplify every arc of the topology at once with
+// geosimplification.SimplifyMultiLine, the arcs' endpoints, where borders
+// meet, are never removed. So polygons sharing a border still share it, and
+// still meet at the same points
+//
+// arcs share one heap and one intersection index, so avoidIntersections
+// stops arcs crossing themselves and one another, and minPointsToKeep counts
+// the vertices of every arc. Closed arcs (whole rings) keep at least 3
+// vertices. WithReport and WithStats describe every arc
+func Simplify(
+	topology *Topology,
+	threshold float64,
+	minPointsToKeep int,
+	avoidIntersections bool,
+	opts ...geosimplification.Option,
+) (*Topology, error) {
+	// keep the positions we're given, not a round trip through s2
+	positions := map[s2.Point]geosimplification.Position{}
+	polylines := make([]s2.Polyline, len(topology.Arcs))
+	for i, arc := range topology.Arcs {
+		polylines[i] = make(s2.Polyline, len(arc))
+		for j, position := range arc {
+			polylines[i][j] = pointFromPosition(position)
+			positions[polylines[i][j]] = position
+		}
+	}
+
+	simplified, err := geosimplification.SimplifyMultiLine(polylines, threshold, minPointsToKeep, avoidIntersections, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &Topology{
+		Objects: topology.Objects,
+		Arcs:    make([][]geosimplification.Position, len(topology.Arcs)),
+	}
+	for i, polyline := range simplified {
+		output.Arcs[i] = make([]geosimplification.Position, len(polyline))
+		for j, point := range polyline {
+			position, ok := positions[point]
+			if !ok {
+				// added, e.g: by geosimplification.Densify
+				latLng := s2.LatLngFromPoint(point)
+				position = geosimplification.Position{latLng.Lng.Degrees(), latLng.Lat.Degrees()}
+			}
+			output.Arcs[i][j] = position
+		}
+	}
+	return output, nil
+}
+
+// The polylines of a LineString or MultiLineString
+func (t *Topology) Polylines(geometry *Geometry) ([]s2.Polyline, error) {
+	if geometry.Type != "LineString" && geometry.Type != "MultiLineString" {
+		return nil, fmt.Errorf("expected a LineString or MultiLineString, got `%s`", geometry.Type)
+	}
+	polylines := []s2.Polyline{}
+	for _, lines := range geometry.Arcs {
+		for _, line := range lines {
+			positions, err := t.stitch(line)
+			if err != nil {
+				return nil, err
+			}
+			polyline := make(s2.Polyline, len(positions))
+			for i, position := range positions {
+				polyline[i] = pointFromPosition(position)
+			}
+			polylines = append(polylines, polyline)
+		}
+	}
+	return polylines, nil
+}
+
+// The polygons of a Polygon or MultiPolygon
+// rings may wind either way, a ring inside another is a hole
+func (t *Topology) Polygons(geometry *Geometry) ([]*s2.Polygon, error) {
+	if geometry.Type != "Polygon" && geometry.Type != "MultiPolygon" {
+		return nil, fmt.Errorf("expected a Polygon or MultiPolygon, got `%s`", geometry.Type)
+	}
+	polygons := make([]*s2.Polygon, len(geometry.Arcs))
+	for i, rings := range geometry.Arcs {
+		loops := make([]*s2.Loop, len(rings))
+		for j, ring := range rings {
+			positions, err := t.stitch(ring)
+			if err != nil {
+				return nil, err
+			}
+			loops[j] = geosimplification.LoopFromRing(positions)
+			loops[j].Normalize()
+		}
+		polygons[i] = s2.PolygonFromLoops(loops)
+	}
+	return polygons, nil
+}
+
+// Join arcs end to end, each arc starts where the last ended
+func (t *Topology) stitch(arcs []int) ([]geosimplification.Position, error) {
+	positions := []geosimplification.Position{}
+	for _, index := range arcs {
+		reversed := index < 0
+		if reversed {
+			index = ^index
+		}
+		if index >= len(t.Arcs) {
+			return nil, fmt.Errorf("arc `%d` doesn't exist", index)
+		}
+		arc := t.Arcs[index]
+		for i := range arc {
+			position := arc[i]
+			if reversed {
+				position = arc[len(arc)-1-i]
+			}
+			// the first position repeats the last arc's end
+			if i == 0 && len(positions) > 0 {
+				continue
+			}
+			positions = append(positions, position)
+		}
+	}
+	return positions, nil
+}
+
+func pointFromPosition(position geosimplification.Position) s2.Point {
+	return s2.PointFromLatLng(s2.LatLngFromDegrees(position[1], position[0]))
+}
@@ -0,0 +1,13 @@
+package topojson_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestTopoJSON(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TopoJSON Suite")
+}
@@ -0,0 +1,300 @@
+// Reads and writes TopoJSON, and simplifies its arcs
+//
+// shared borders are stored once as arcs, so simplifying each arc once keeps
+// neighbouring polygons meeting exactly, no gaps or overlaps
+// https://github.com/topojson/topojson-specification
+package topojson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+// Positions are [longitude, latitude] in degrees, once dequantized
+type Topology struct {
+	Objects map[string]*Geometry
+	Arcs    [][]geosimplification.Position
+}
+
+type Geometry struct {
+	// Point, MultiPoint, LineString, MultiLineString, Polygon, MultiPolygon
+	// or GeometryCollection
+	Type       string
+	ID         interface{}
+	Properties map[string]interface{}
+	// Indices into Topology.Arcs, ^i (-i-1) is arc i reversed
+	// MultiPolygon depth for every type: a LineString is Arcs[0][0],
+	// MultiLineString and Polygon Arcs[0]
+	Arcs [][][]int
+	// Point is Coordinates[0]
+	Coordinates []geosimplification.Position
+	Geometries  []*Geometry
+}
+
+type transform struct {
+	Scale     [2]float64 `json:"scale"`
+	Translate [2]float64 `json:"translate"`
+}
+
+// The TopoJSON as written
+type wireTopology struct {
+	Type      string                   `json:"type"`
+	BBox      []float64                `json:"bbox,omitempty"`
+	Transform *transform               `json:"transform,omitempty"`
+	Objects   map[string]*wireGeometry `json:"objects"`
+	Arcs      [][][2]float64           `json:"arcs"`
+}
+
+type wireGeometry struct {
+	Type        string                 `json:"type"`
+	ID          interface{}            `json:"id,omitempty"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+	Arcs        json.RawMessage        `json:"arcs,omitempty"`
+	Coordinates json.RawMessage        `json:"coordinates,omitempty"`
+	Geometries  []*wireGeometry        `json:"geometries,omitempty"`
+}
+
+// Parse a topology, quantized (delta encoded) arcs are decoded
+func Read(data []byte) (*Topology, error) {
+	wire := wireTopology{}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return nil, err
+	}
+	if wire.Type != "Topology" {
+		return nil, fmt.Errorf("expected a Topology, got `%s`", wire.Type)
+	}
+
+	topology := &Topology{
+		Objects: make(map[string]*Geometry, len(wire.Objects)),
+		Arcs:    make([][]geosimplification.Position, len(wire.Arcs)),
+	}
+	for i, arc := range wire.Arcs {
+		topology.Arcs[i] = make([]geosimplification.Position, len(arc))
+		x, y := 0.0, 0.0
+		for j, position := range arc {
+			if wire.Transform == nil {
+				topology.Arcs[i][j] = position
+				continue
+			}
+			x, y = x+position[0], y+position[1]
+			topology.Arcs[i][j] = wire.Transform.decode(x, y)
+		}
+	}
+	for name, object := range wire.Objects {
+		geometry, err := object.decode(wire.Transform)
+		if err != nil {
+			return nil, fmt.Errorf("object `%s`: %w", name, err)
+		}
+		topology.Objects[name] = geometry
+	}
+	return topology, nil
+}
+
+func (t *transform) decode(x, y float64) geosimplification.Position {
+	return geosimplification.Position{
+		x*t.Scale[0] + t.Translate[0],
+		y*t.Scale[1] + t.Translate[1],
+	}
+}
+
+func (w *wireGeometry) decode(transform *transform) (*Geometry, error) {
+	geometry := &Geometry{Type: w.Type, ID: w.ID, Properties: w.Properties}
+	var err error
+	switch w.Type {
+	case "Point":
+		position := geosimplification.Position{}
+		err = json.Unmarshal(w.Coordinates, &position)
+		geometry.Coordinates = []geosimplification.Position{position}
+	case "MultiPoint":
+		err = json.Unmarshal(w.Coordinates, &geometry.Coordinates)
+	case "LineString":
+		line := []int{}
+		err = json.Unmarshal(w.Arcs, &line)
+		geometry.Arcs = [][][]int{{line}}
+	case "MultiLineString", "Polygon":
+		lines := [][]int{}
+		err = json.Unmarshal(w.Arcs, &lines)
+		geometry.Arcs = [][][]int{lines}
+	case "MultiPolygon":
+		err = json.Unmarshal(w.Arcs, &geometry.Arcs)
+	case "GeometryCollection":
+		geometry.Geometries = make([]*Geometry, len(w.Geometries))
+		for i, child := range w.Geometries {
+			if geometry.Geometries[i], err = child.decode(transform); err != nil {
+				return nil, fmt.Errorf("geometry `%d`: %w", i, err)
+			}
+		}
+	case "":
+		// a null geometry
+	default:
+		return nil, fmt.Errorf("unknown geometry type `%s`", w.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// points are quantized, but not delta encoded
+	if transform != nil {
+		for i, position := range geometry.Coordinates {
+			geometry.Coordinates[i] = transform.decode(position[0], position[1])
+		}
+	}
+	return geometry, nil
+}
+
+// Serialise the topology
+// with a quantization of 0 positions are written as they are, otherwise
+// they're rounded to a `quantization` by `quantization` grid over the
+// bounding box and arcs are delta encoded
+func (t *Topology) Write(quantization int) ([]byte, error) {
+	if quantization == 1 || quantization < 0 {
+		return nil, errors.New("quantization must be 0, or at least 2")
+	}
+
+	wire := wireTopology{
+		Type:    "Topology",
+		Objects: make(map[string]*wireGeometry, len(t.Objects)),
+		Arcs:    make([][][2]float64, len(t.Arcs)),
+	}
+	min, max := t.bound()
+	if !math.IsInf(min[0], 1) {
+		wire.BBox = []float64{min[0], min[1], max[0], max[1]}
+	}
+	if quantization > 0 && wire.BBox != nil {
+		wire.Transform = &transform{Translate: min}
+		for i := range min {
+			wire.Transform.Scale[i] = 1
+			if max[i] > min[i] {
+				wire.Transform.Scale[i] = (max[i] - min[i]) / float64(quantization-1)
+			}
+		}
+	}
+
+	for i, arc := range t.Arcs {
+		wire.Arcs[i] = make([][2]float64, 0, len(arc))
+		if wire.Transform == nil {
+			for _, position := range arc {
+				wire.Arcs[i] = append(wire.Arcs[i], position)
+			}
+			continue
+		}
+		for j, position := range arc {
+			quantized := wire.Transform.encode(position)
+			// quantizing can collapse neighbouring positions
+			if j > 0 && quantized == wire.Arcs[i][len(wire.Arcs[i])-1] {
+				continue
+			}
+			wire.Arcs[i] = append(wire.Arcs[i], quantized)
+		}
+		// an arc needs two positions, even if they're the same
+		if len(wire.Arcs[i]) == 1 {
+			wire.Arcs[i] = append(wire.Arcs[i], wire.Arcs[i][0])
+		}
+		// delta encode, back to front
+		for j := len(wire.Arcs[i]) - 1; j > 0; j-- {
+			wire.Arcs[i][j][0] -= wire.Arcs[i][j-1][0]
+			wire.Arcs[i][j][1] -= wire.Arcs[i][j-1][1]
+		}
+	}
+
+	for name, geometry := range t.Objects {
+		object, err := geometry.encode(wire.Transform)
+		if err != nil {
+			return nil, fmt.Errorf("object `%s`: %w", name, err)
+		}
+		wire.Objects[name] = object
+	}
+	return json.Marshal(wire)
+}
+
+func (t *transform) encode(position geosimplification.Position) [2]float64 {
+	return [2]float64{
+		math.Round((position[0] - t.Translate[0]) / t.Scale[0]),
+		math.Round((position[1] - t.Translate[1]) / t.Scale[1]),
+	}
+}
+
+func (g *Geometry) encode(transform *transform) (*wireGeometry, error) {
+	wire := &wireGeometry{Type: g.Type, ID: g.ID, Properties: g.Properties}
+	coordinates := make([][2]float64, len(g.Coordinates))
+	for i, position := range g.Coordinates {
+		coordinates[i] = position
+		if transform != nil {
+			coordinates[i] = transform.encode(position)
+		}
+	}
+
+	var value interface{}
+	switch g.Type {
+	case "Point":
+		if len(coordinates) != 1 {
+			return nil, fmt.Errorf("a Point has 1 position, got `%d`", len(coordinates))
+		}
+		wire.Coordinates, _ = json.Marshal(coordinates[0])
+		return wire, nil
+	case "MultiPoint":
+		wire.Coordinates, _ = json.Marshal(coordinates)
+		return wire, nil
+	case "LineString":
+		if len(g.Arcs) != 1 || len(g.Arcs[0]) != 1 {
+			return nil, errors.New("a LineString has one line of arcs")
+		}
+		value = g.Arcs[0][0]
+	case "MultiLineString", "Polygon":
+		if len(g.Arcs) != 1 {
+			return nil, fmt.Errorf("a %s has one list of arcs", g.Type)
+		}
+		value = g.Arcs[0]
+	case "MultiPolygon":
+		value = g.Arcs
+	case "GeometryCollection":
+		wire.Geometries = make([]*wireGeometry, len(g.Geometries))
+		for i, child := range g.Geometries {
+			var err error
+			if wire.Geometries[i], err = child.encode(transform); err != nil {
+				return nil, fmt.Errorf("geometry `%d`: %w", i, err)
+			}
+		}
+		return wire, nil
+	case "":
+		return wire, nil
+	default:
+		return nil, fmt.Errorf("unknown geometry type `%s`", g.Type)
+	}
+	var err error
+	wire.Arcs, err = json.Marshal(value)
+	return wire, err
+}
+
+// The bounding box of every arc and point
+func (t *Topology) bound() (min, max [2]float64) {
+	min = [2]float64{math.Inf(1), math.Inf(1)}
+	max = [2]float64{math.Inf(-1), math.Inf(-1)}
+	extend := func(position geosimplification.Position) {
+		for i := range position {
+			min[i] = math.Min(min[i], position[i])
+			max[i] = math.Max(max[i], position[i])
+		}
+	}
+	for _, arc := range t.Arcs {
+		for _, position := range arc {
+			extend(position)
+		}
+	}
+	var points func(geometry *Geometry)
+	points = func(geometry *Geometry) {
+		for _, position := range geometry.Coordinates {
+			extend(position)
+		}
+		for _, child := range geometry.Geometries {
+			points(child)
+		}
+	}
+	for _, geometry := range t.Objects {
+		points(geometry)
+	}
+	return min, max
+}
@@ -0,0 +1,157 @@
+package topojson_test
+
+import (
+	"math"
+
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+	"gitlab.com/hcliff/geo-simplification/topojson"
+)
+
+// The example from the specification, quantized and delta encoded
+const example = `{
+	"type": "Topology",
+	"transform": {"scale": [0.0005000500050005, 0.00010001000100010001], "translate": [100, 0]},
+	"objects": {"example": {"type": "GeometryCollection", "geometries": [
+		{"type": "Point", "properties": {"prop0": "value0"}, "coordinates": [4000, 5000]},
+		{"type": "LineString", "properties": {"prop0": "value0", "prop1": 0}, "arcs": [0]},
+		{"type": "Polygon", "properties": {"prop0": "value0", "prop1": {"this": "that"}}, "arcs": [[-2]]}
+	]}},
+	"arcs": [
+		[[4000, 0], [1999, 9999], [2000, -9999], [2000, 9999]],
+		[[0, 0], [0, 9999], [2000, 0], [0, -9999], [-2000, 0]]
+	]
+}`
+
+// Two unit squares side by side, sharing a jagged border (arc 0)
+func neighbours() *topojson.Topology {
+	border := []geosimplification.Position{}
+	for i := 0; i <= 10; i++ {
+		border = append(border, geosimplification.Position{1 + math.Mod(float64(i), 2)*0.001, float64(i) * 0.1})
+	}
+	return &topojson.Topology{
+		Objects: map[string]*topojson.Geometry{
+			"left":  {Type: "Polygon", Arcs: [][][]int{{{0, 1}}}},
+			"right": {Type: "Polygon", Arcs: [][][]int{{{^0, 2}}}},
+		},
+		Arcs: [][]geosimplification.Position{
+			border,
+			{{1, 1}, {0, 1}, {0, 0}, {1, 0}},
+			{{1, 0}, {2, 0}, {2, 1}, {1, 1}},
+		},
+	}
+}
+
+func expectPositions(actual, expected []geosimplification.Position, tolerance float64) {
+	Ω(actual).Should(HaveLen(len(expected)))
+	for i := range expected {
+		Ω(actual[i][0]).Should(BeNumerically("~", expected[i][0], tolerance))
+		Ω(actual[i][1]).Should(BeNumerically("~", expected[i][1], tolerance))
+	}
+}
+
+var _ = Describe("TopoJSON unit tests", func() {
+
+	It("should decode quantized topologies", func() {
+		topology, err := topojson.Read([]byte(example))
+		Ω(err).Should(BeNil())
+		expectPositions(topology.Arcs[0], []geosimplification.Position{{102, 0}, {103, 1}, {104, 0}, {105, 1}}, 1e-3)
+		expectPositions(topology.Arcs[1], []geosimplification.Position{{100, 0}, {100, 1}, {101, 1}, {101, 0}, {100, 0}}, 1e-3)
+
+		geometries := topology.Objects["example"].Geometries
+		Ω(geometries).Should(HaveLen(3))
+		expectPositions(geometries[0].Coordinates, []geosimplification.Position{{102, 0.5}}, 1e-3)
+		Ω(geometries[1].Arcs).Should(Equal([][][]int{{{0}}}))
+		Ω(geometries[2].Properties).Should(HaveKeyWithValue("prop0", "value0"))
+
+		polylines, err := topology.Polylines(geometries[1])
+		Ω(err).Should(BeNil())
+		Ω(polylines).Should(HaveLen(1))
+		Ω(polylines[0]).Should(HaveLen(4))
+
+		polygons, err := topology.Polygons(geometries[2])
+		Ω(err).Should(BeNil())
+		Ω(polygons).Should(HaveLen(1))
+		// a one degree square on the equator, not the rest of the world
+		Ω(polygons[0].Area()).Should(BeNumerically("~", math.Pow(math.Pi/180, 2), 1e-6))
+	})
+
+	It("should round trip, quantized or not", func() {
+		topology, err := topojson.Read([]byte(example))
+		Ω(err).Should(BeNil())
+
+		exact, err := topology.Write(0)
+		Ω(err).Should(BeNil())
+		read, err := topojson.Read(exact)
+		Ω(err).Should(BeNil())
+		Ω(read).Should(Equal(topology))
+
+		quantized, err := topology.Write(1e4)
+		Ω(err).Should(BeNil())
+		Ω(string(quantized)).Should(ContainSubstring(`"transform"`))
+		read, err = topojson.Read(quantized)
+		Ω(err).Should(BeNil())
+		for i := range topology.Arcs {
+			expectPositions(read.Arcs[i], topology.Arcs[i], 1e-3)
+		}
+		Ω(read.Objects["example"].Geometries[2].Arcs).Should(Equal([][][]int{{{^1}}}))
+
+		_, err = topology.Write(1)
+		Ω(err).ShouldNot(BeNil())
+	})
+
+	It("should simplify shared borders once", func() {
+		topology := neighbours()
+		simplified, err := topojson.Simplify(topology, 1e-5, 0, true)
+		Ω(err).Should(BeNil())
+		Ω(simplified.Arcs[0]).Should(Equal([]geosimplification.Position{{1, 0}, {1, 1}}))
+		// the corners are the endpoints, and significant
+		Ω(simplified.Arcs[1]).Should(Equal(topology.Arcs[1]))
+		// the original is left alone
+		Ω(topology.Arcs[0]).Should(HaveLen(11))
+
+		left, err := simplified.Polygons(simplified.Objects["left"])
+		Ω(err).Should(BeNil())
+		right, err := simplified.Polygons(simplified.Objects["right"])
+		Ω(err).Should(BeNil())
+		Ω(left[0].NumEdges()).Should(Equal(4))
+		Ω(right[0].NumEdges()).Should(Equal(4))
+		// no gap, no overlap
+		square := geosimplification.LoopFromRing([]geosimplification.Position{{0, 0}, {1, 0}, {1, 1}, {0, 1}})
+		Ω(left[0].Area() + right[0].Area()).Should(BeNumerically("~", 2*square.Area(), 1e-12))
+		Ω(left[0].ContainsPoint(s2.PointFromLatLng(s2.LatLngFromDegrees(0.5, 0.5)))).Should(BeTrue())
+	})
+
+	It("should keep closed arcs closed", func() {
+		topology, err := topojson.Read([]byte(example))
+		Ω(err).Should(BeNil())
+		simplified, err := topojson.Simplify(topology, math.Inf(1), 0, true)
+		Ω(err).Should(BeNil())
+		Ω(simplified.Arcs[0]).Should(HaveLen(2))
+		Ω(simplified.Arcs[1]).Should(HaveLen(4))
+		Ω(simplified.Arcs[1][0]).Should(Equal(simplified.Arcs[1][3]))
+	})
+
+	It("should keep arcs from crossing one another", func() {
+		// a tent, and a post standing under its ridge
+		topology := &topojson.Topology{
+			Objects: map[string]*topojson.Geometry{},
+			Arcs: [][]geosimplification.Position{
+				{{0, 0}, {1, 1}, {2, 0}},
+				{{1, 0.5}, {1, -0.5}},
+			},
+		}
+		var stats geosimplification.Stats
+		simplified, err := topojson.Simplify(topology, math.Inf(1), 0, true, geosimplification.WithStats(&stats))
+		Ω(err).Should(BeNil())
+		Ω(simplified.Arcs[0]).Should(Equal(topology.Arcs[0]))
+		Ω(stats.InputVertices).Should(Equal(5))
+		Ω(stats.OutputVertices).Should(Equal(5))
+
+		crossing, err := topojson.Simplify(topology, math.Inf(1), 0, false)
+		Ω(err).Should(BeNil())
+		Ω(crossing.Arcs[0]).Should(HaveLen(2))
+	})
+})
@@ -0,0 +1,73 @@
+package geosimplification
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+)
+
+// Web Mercator can't show anything past ~85.05 degrees
+var maxMercatorLatitude = s1.Angle(math.Atan(math.Sinh(math.Pi)))
+
+// The distance on the earth `pixels` tile pixels cover at `latitude`
+// for tiles `extent` pixels wide (256, 512, 4096 for MVT) at `zoom`
+func ZoomTolerance(zoom, extent int, pixels float64, latitude s1.Angle) s1.Angle {
+	if latitude.Abs() > maxMercatorLatitude {
+		latitude = maxMercatorLatitude
+	}
+	// the whole world is one tile at zoom 0, mercator stretches it by 1/cos(lat)
+	world := float64(extent) * math.Exp2(float64(zoom))
+	return s1.Angle(pixels * 2 * math.Pi * math.Cos(latitude.Radians()) / world)
+}
+
+// The Visvalingam threshold (steradians) for ZoomTolerance
+// a vertex is removed if its triangle is smaller than a `pixels` square
+func ZoomThreshold(zoom, extent int, pixels float64, latitude s1.Angle) float64 {
+	tolerance := ZoomTolerance(zoom, extent, pixels, latitude).Radians()
+	return tolerance * tolerance
+}
+
+type zoomTolerance struct {
+	zoom, extent int
+	pixels       float64
+}
+
+// Ignore the threshold passed to SimplifyLine / SimplifyLoop and use
+// ZoomThreshold instead, at the latitude of the middle of the shape
+func AtZoom(zoom, extent int, pixels float64) Option {
+	return func(o *options) {
+		o.zoom = &zoomTolerance{zoom: zoom, extent: extent, pixels: pixels}
+	}
+}
+
+// Tiles have pixels, and a tolerance of no pixels is no tolerance
+func (o *options) validateZoom() error {
+	if o.zoom == nil {
+		return nil
+	}
+	if o.zoom.extent <= 0 {
+		return fmt.Errorf("tile extent `%d` must be positive", o.zoom.extent)
+	}
+	if o.zoom.pixels < 0 {
+		return fmt.Errorf("pixel tolerance `%v` can't be negative", o.zoom.pixels)
+	}
+	return nil
+}
+
+// The threshold to simplify a shape with bounds `bound` at
+func (o *options) threshold(threshold float64, bound s2.Rect) float64 {
+	if o.zoom == nil {
+		return threshold
+	}
+	return ZoomThreshold(o.zoom.zoom, o.zoom.extent, o.zoom.pixels, bound.Center().Lat)
+}
+
+// The tolerance to simplify a shape with bounds `bound` at
+func (o *options) tolerance(tolerance s1.Angle, bound s2.Rect) s1.Angle {
+	if o.zoom == nil {
+		return tolerance
+	}
+	return ZoomTolerance(o.zoom.zoom, o.zoom.extent, o.zoom.pixels, bound.Center().Lat)
+}
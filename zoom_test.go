@@ -0,0 +1,64 @@
+package geosimplification_test
+
+import (
+	"math"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	geosimplification "gitlab.com/hcliff/geo-simplification"
+)
+
+var _ = Describe("Zoom level unit tests", func() {
+
+	It("should cover the world with one tile at zoom 0", func() {
+		tolerance := geosimplification.ZoomTolerance(0, 256, 256, 0)
+		Ω(tolerance.Radians()).Should(BeNumerically("~", 2*math.Pi, 1e-12))
+	})
+
+	It("should halve the tolerance each zoom level", func() {
+		z8 := geosimplification.ZoomTolerance(8, 4096, 1, 0)
+		z9 := geosimplification.ZoomTolerance(9, 4096, 1, 0)
+		Ω(z9.Radians()).Should(BeNumerically("~", z8.Radians()/2, 1e-15))
+		Ω(geosimplification.ZoomThreshold(8, 4096, 1, 0)).Should(BeNumerically("~", z8.Radians()*z8.Radians(), 1e-20))
+	})
+
+	It("should shrink the tolerance away from the equator", func() {
+		equator := geosimplification.ZoomTolerance(8, 4096, 1, 0)
+		north := geosimplification.ZoomTolerance(8, 4096, 1, 60*s1.Degree)
+		Ω(north.Radians()).Should(BeNumerically("~", equator.Radians()/2, 1e-15))
+		// nothing to see past the edge of the map, don't collapse to zero
+		pole := geosimplification.ZoomTolerance(8, 4096, 1, 90*s1.Degree)
+		Ω(pole.Radians()).Should(BeNumerically(">", 0))
+	})
+
+	It("should simplify with the threshold for the zoom level", func() {
+		latLngs := []s2.LatLng{}
+		for i := 0; i < 100; i++ {
+			latLngs = append(latLngs, s2.LatLngFromDegrees(50+math.Sin(float64(i))*0.01, float64(i)*0.01))
+		}
+		polyline := *s2.PolylineFromLatLngs(latLngs)
+		threshold := geosimplification.ZoomThreshold(6, 4096, 1, polyline.RectBound().Center().Lat)
+
+		expected, err := geosimplification.SimplifyLine(polyline, threshold, 0, true)
+		Ω(err).Should(BeNil())
+		simplified, err := geosimplification.SimplifyLine(polyline, 0, 0, true, geosimplification.AtZoom(6, 4096, 1))
+		Ω(err).Should(BeNil())
+		Ω(simplified).Should(Equal(expected))
+		Ω(len(simplified)).Should(BeNumerically("<", len(polyline)))
+	})
+
+	It("should reject tiles without pixels", func() {
+		polyline := s2.Polyline(points([2]float64{0, 0}, [2]float64{0, 1}, [2]float64{0.1, 2}))
+		_, err := geosimplification.SimplifyLine(polyline, 0, 0, true, geosimplification.AtZoom(6, 0, 1))
+		Ω(err).Should(HaveOccurred())
+		_, err = geosimplification.SimplifyLine(polyline, 0, 0, true, geosimplification.AtZoom(6, 4096, -1))
+		Ω(err).Should(HaveOccurred())
+
+		loop := s2.LoopFromPoints(points([2]float64{0, 0}, [2]float64{0, 1}, [2]float64{1, 1}, [2]float64{1, 0}))
+		loop.Normalize()
+		_, err = geosimplification.SimplifyLoop(loop, 0, 0, true, geosimplification.AtZoom(6, -256, 1))
+		Ω(err).Should(HaveOccurred())
+	})
+})